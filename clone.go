@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/lilic/gisty/gist"
+)
+
+// metadataFile records which gist a cloned directory belongs to.
+const metadataFile = ".gisty.json"
+
+// cloneMetadata is the content of metadataFile.
+type cloneMetadata struct {
+	ID     string `json:"id"`
+	URL    string `json:"url"`
+	APIURL string `json:"api_url"`
+	// Profile is the profile the gist was cloned with, whose token is the
+	// one allowed to push to APIURL.
+	Profile  string `json:"profile,omitempty"`
+	Revision string `json:"revision"`
+	// Files maps every file name to the SHA-256 of its content at
+	// Revision, to tell which files were changed locally.
+	Files map[string]string `json:"files"`
+}
+
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// newCloneMetadata describes the state of g as written to disk.
+func newCloneMetadata(g *gist.Gist, o Options) *cloneMetadata {
+	m := &cloneMetadata{
+		ID:      g.ID,
+		URL:     g.HTMLURL,
+		APIURL:  o.APIURL,
+		Profile: o.Profile,
+		Files:   map[string]string{},
+	}
+	if len(g.History) > 0 {
+		m.Revision = g.History[0].Version
+	}
+	for name, f := range g.Files {
+		m.Files[string(name)] = contentHash(f.Content)
+	}
+	return m
+}
+
+func readCloneMetadata(dir string) (*cloneMetadata, error) {
+	c, err := ioutil.ReadFile(filepath.Join(dir, metadataFile))
+	if err != nil {
+		return nil, err
+	}
+	m := &cloneMetadata{}
+	if err := json.Unmarshal(c, m); err != nil {
+		return nil, fmt.Errorf("%s: %v", filepath.Join(dir, metadataFile), err)
+	}
+	return m, nil
+}
+
+func writeCloneMetadata(dir string, m *cloneMetadata) error {
+	c, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, metadataFile), append(c, '\n'), 0644)
+}
+
+func runClone(ctx context.Context, o Options) int {
+	token, ok := authToken(o)
+	if !ok {
+		return 1
+	}
+	dir := o.Clone
+	if len(o.Args) > 0 {
+		dir = o.Args[0]
+	}
+	g, err := newClient(o, token).ShowContext(ctx, o.Clone)
+	if err != nil {
+		return printError(o, err, o.Clone)
+	}
+
+	// Refuse before writing anything if any file is in the way.
+	if !o.Force {
+		conflict := false
+		for _, name := range append(fileNames(g), metadataFile) {
+			path := filepath.Join(dir, name)
+			if _, err := os.Lstat(path); err == nil {
+				fmt.Printf("%s already exists.\n", path)
+				conflict = true
+			}
+		}
+		if conflict {
+			fmt.Println("Use --force to overwrite.")
+			return 1
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	for name, f := range g.Files {
+		if err := ioutil.WriteFile(filepath.Join(dir, string(name)), []byte(f.Content), 0644); err != nil {
+			fmt.Println(err)
+			return 1
+		}
+	}
+	m := newCloneMetadata(g, o)
+	if err := writeCloneMetadata(dir, m); err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	fmt.Printf("Cloned gist %s at revision %s into %s.\n", g.ID, shortRevision(m.Revision), dir)
+	return 0
+}
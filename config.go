@@ -0,0 +1,192 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	flag "github.com/spf13/pflag"
+)
+
+const (
+	gistyConfig  = "GISTY_CONFIG"
+	gistyProfile = "GISTY_PROFILE"
+)
+
+// configFlags are the flags that can be given defaults in the config file.
+// Flags choosing what gisty does, such as --delete or --yes, and flags
+// naming gists or files are left out, since a default for them would apply
+// to every command.
+var configFlags = map[string]bool{
+	"public": true, "filename": true, "description-prefix": true,
+	"theme": true, "line-numbers": true, "no-pager": true,
+	"output": true, "format": true, "verbose": true,
+	"per-page": true, "limit": true, "all": true,
+	"interval": true, "debounce": true,
+	"api-url": true, "max-wait": true, "retries": true, "timeout": true,
+}
+
+// config holds the settings of the config file, keyed by flag name, e.g.
+//
+//	public: false
+//	filename: notes.md
+//	description-prefix: "[notes]"
+//	api-url: https://github.example.com/api/v3
+//	editor: code --wait
+//	theme: bright
+//
+// Only the configFlags can be set, and a setting is only used when its flag
+// is not given on the command line.
+// Besides the flags there is editor, which takes precedence over $EDITOR,
+// and token-env or token-command, which name the ENV variable holding the
+// token instead of $GITHUB_TOKEN, or a command printing it.
+//
+// Named profiles hold settings for another account or host, and take
+// precedence over the ones outside when selected with --profile,
+// $GISTY_PROFILE or a profile setting:
+//
+//	profiles:
+//	  work:
+//	    api-url: https://github.example.com/api/v3
+//	    token-command: pass show github/work
+//	    public: false
+type config struct {
+	path     string
+	settings map[string]interface{}
+}
+
+// configPath returns $GISTY_CONFIG, or gisty/config.yaml in
+// $XDG_CONFIG_HOME or ~/.config. explicit is set for $GISTY_CONFIG, which
+// must exist.
+func configPath() (path string, explicit bool) {
+	if p := os.Getenv(gistyConfig); p != "" {
+		return p, true
+	}
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", false
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "gisty", "config.yaml"), false
+}
+
+// loadConfig reads the config file. Without one, the config is empty.
+func loadConfig() (*config, error) {
+	path, explicit := configPath()
+	c := &config{path: path, settings: map[string]interface{}{}}
+	if path == "" {
+		return c, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) && !explicit {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if c.settings, err = readYAML(data); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return c, nil
+}
+
+// apply sets the flags that were not given on the command line, and the
+// options without a flag, from the settings of the selected profile and
+// then from the others.
+func (c *config) apply(flags *flag.FlagSet, o *Options) error {
+	profiles, ok := c.settings["profiles"].(map[string]interface{})
+	if _, set := c.settings["profiles"]; set && !ok {
+		return fmt.Errorf("%s: profiles must map names to settings", c.path)
+	}
+	if !flags.Changed("profile") {
+		if p := os.Getenv(gistyProfile); p != "" {
+			o.Profile = p
+		} else if p, ok := c.settings["profile"].(string); ok {
+			o.Profile = p
+		}
+	}
+	if o.Profile != "" {
+		settings, ok := profiles[o.Profile].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: no profile %s", c.path, o.Profile)
+		}
+		if err := c.applySettings(settings, flags, o, "profiles."+o.Profile+"."); err != nil {
+			return err
+		}
+	}
+	return c.applySettings(c.settings, flags, o, "")
+}
+
+// applySettings applies one level of settings, whose keys are reported with
+// prefix.
+func (c *config) applySettings(settings map[string]interface{}, flags *flag.FlagSet, o *Options, prefix string) error {
+	_, env := settings["token-env"]
+	_, command := settings["token-command"]
+	if env && command {
+		return fmt.Errorf("%s: %stoken-env and %stoken-command cannot both be set", c.path, prefix, prefix)
+	}
+	// The token source is a single setting: a profile that sets either key
+	// replaces both of the top level.
+	tokenSet := o.TokenEnv != "" || o.TokenCommand != ""
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := settings[key]
+		var option *string
+		switch key {
+		case "profile", "profiles":
+			if prefix == "" {
+				continue
+			}
+		case "editor":
+			option = &o.Editor
+		case "token-env":
+			option = &o.TokenEnv
+		case "token-command":
+			option = &o.TokenCommand
+		}
+		if option != nil {
+			s, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("%s: %s%s takes a single value", c.path, prefix, key)
+			}
+			// The selected profile comes first and wins.
+			if *option == "" && !(tokenSet && strings.HasPrefix(key, "token-")) {
+				*option = s
+			}
+			continue
+		}
+		f := flags.Lookup(key)
+		if f == nil || !configFlags[key] {
+			return fmt.Errorf("%s: unknown setting %s%s", c.path, prefix, key)
+		}
+		if f.Changed {
+			continue
+		}
+		if err := setFlag(flags, f, value); err != nil {
+			return fmt.Errorf("%s: %s%s: %v", c.path, prefix, key, err)
+		}
+	}
+	return nil
+}
+
+// setFlag sets f to a scalar setting.
+func setFlag(flags *flag.FlagSet, f *flag.Flag, value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		return flags.Set(f.Name, v)
+	case []interface{}:
+		return errors.New("takes a single value")
+	}
+	return errors.New("takes a value, not a mapping")
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	flag "github.com/spf13/pflag"
+)
+
+const testConfig = `
+filename: top.txt
+editor: top-editor
+profiles:
+  work:
+    api-url: https://github.example.com/api/v3
+    token-command: pass show work
+    filename: work.txt
+  home:
+    token-env: HOME_TOKEN
+`
+
+func TestConfigApply(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		env    string
+		args   []string
+		want   Options
+		err    string
+	}{
+		{
+			name:   "empty",
+			config: "",
+			want:   Options{Filename: "file1.txt", APIURL: "default"},
+		},
+		{
+			name:   "settings",
+			config: "public: true\nfilename: notes.md\neditor: code --wait\ntoken-env: MY_TOKEN\n",
+			want:   Options{Public: true, Filename: "notes.md", APIURL: "default", Editor: "code --wait", TokenEnv: "MY_TOKEN"},
+		},
+		{
+			name:   "flags win",
+			config: "public: true\nfilename: notes.md\n",
+			args:   []string{"--public=false", "--filename=cli.txt"},
+			want:   Options{Filename: "cli.txt", APIURL: "default"},
+		},
+		{
+			name:   "no profile selected",
+			config: testConfig,
+			want:   Options{Filename: "top.txt", APIURL: "default", Editor: "top-editor"},
+		},
+		{
+			name:   "profile flag",
+			config: testConfig,
+			args:   []string{"--profile=work"},
+			want: Options{
+				Profile: "work", Filename: "work.txt",
+				APIURL: "https://github.example.com/api/v3", Editor: "top-editor", TokenCommand: "pass show work",
+			},
+		},
+		{
+			name:   "profile flag over env and flags over profile",
+			config: testConfig,
+			env:    "home",
+			args:   []string{"--profile=work", "--filename=cli.txt", "--api-url=https://other/api/v3"},
+			want: Options{
+				Profile: "work", Filename: "cli.txt",
+				APIURL: "https://other/api/v3", Editor: "top-editor", TokenCommand: "pass show work",
+			},
+		},
+		{
+			name:   "profile env",
+			config: testConfig,
+			env:    "home",
+			want:   Options{Profile: "home", Filename: "top.txt", APIURL: "default", Editor: "top-editor", TokenEnv: "HOME_TOKEN"},
+		},
+		{
+			name:   "profile setting",
+			config: "profile: home\n" + testConfig,
+			want:   Options{Profile: "home", Filename: "top.txt", APIURL: "default", Editor: "top-editor", TokenEnv: "HOME_TOKEN"},
+		},
+		{
+			name:   "profile editor wins",
+			config: "editor: top\nprofiles:\n  work:\n    editor: work\n",
+			args:   []string{"--profile=work"},
+			want:   Options{Profile: "work", Filename: "file1.txt", APIURL: "default", Editor: "work"},
+		},
+		{
+			name:   "profile token source replaces top level",
+			config: "token-command: pass show personal\nprofiles:\n  work:\n    api-url: https://github.example.com/api/v3\n    token-env: WORK_TOKEN\n",
+			args:   []string{"--profile=work"},
+			want:   Options{Profile: "work", Filename: "file1.txt", APIURL: "https://github.example.com/api/v3", TokenEnv: "WORK_TOKEN"},
+		},
+		{
+			name:   "top level token source without profile",
+			config: "token-command: pass show personal\nprofiles:\n  work:\n    token-env: WORK_TOKEN\n",
+			want:   Options{Filename: "file1.txt", APIURL: "default", TokenCommand: "pass show personal"},
+		},
+		{name: "both token sources", config: "token-env: A\ntoken-command: b\n", err: "token-env and token-command cannot both be set"},
+		{name: "both token sources in profile", config: "profiles:\n  work:\n    token-env: A\n    token-command: b\n", args: []string{"--profile=work"}, err: "profiles.work.token-env and profiles.work.token-command cannot both be set"},
+		{name: "missing profile", config: testConfig, args: []string{"--profile=nope"}, err: "no profile nope"},
+		{name: "profile without config", config: "", env: "work", err: "no profile work"},
+		{name: "unknown key", config: "bogus: 1\n", err: "unknown setting bogus"},
+		{name: "unknown key in profile", config: "profiles:\n  work:\n    bogus: 1\n", args: []string{"--profile=work"}, err: "unknown setting profiles.work.bogus"},
+		{name: "nested profile", config: "profiles:\n  work:\n    profile: home\n", args: []string{"--profile=work"}, err: "unknown setting profiles.work.profile"},
+		{name: "profiles not a mapping", config: "profiles: work\n", err: "profiles must map names to settings"},
+		{name: "file list", config: "file: [a.go, b.go]\n", err: "unknown setting file"},
+		{name: "action flag", config: "delete: [abc]\nyes: true\n", err: "unknown setting delete"},
+		{name: "action flag in profile", config: "profiles:\n  work:\n    yes: true\n", args: []string{"--profile=work"}, err: "unknown setting profiles.work.yes"},
+		{name: "sequence for single value", config: "filename: [a, b]\n", err: "filename: takes a single value"},
+		{name: "sequence for editor", config: "editor: [a, b]\n", err: "editor takes a single value"},
+		{name: "mapping for flag", config: "filename:\n  a: b\n", err: "takes a value, not a mapping"},
+		{name: "invalid value", config: "public: maybe\n", err: "public:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(gistyProfile, tt.env)
+			settings, err := readYAML([]byte(tt.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var o Options
+			flags := flag.NewFlagSet("gisty", flag.ContinueOnError)
+			flags.BoolVar(&o.Public, "public", false, "")
+			flags.StringVar(&o.Filename, "filename", "file1.txt", "")
+			flags.StringArrayVar(&o.Files, "file", nil, "")
+			flags.StringSliceVar(&o.Delete, "delete", nil, "")
+			flags.BoolVar(&o.Yes, "yes", false, "")
+			flags.StringVar(&o.Profile, "profile", "", "")
+			flags.StringVar(&o.APIURL, "api-url", "default", "")
+			if err := flags.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			err = (&config{path: "config.yaml", settings: settings}).apply(flags, &o)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("apply() = %v, want error containing %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("apply() failed: %v", err)
+			}
+			if !reflect.DeepEqual(o, tt.want) {
+				t.Errorf("apply() set\n%+v\nwant\n%+v", o, tt.want)
+			}
+		})
+	}
+}
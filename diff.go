@@ -0,0 +1,396 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	colour "github.com/fatih/color"
+	"github.com/lilic/gisty/gist"
+)
+
+// diffContext is the number of unchanged lines shown around a change.
+const diffContext = 3
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+// diffOp is one line of an edit script turning a into b.
+type diffOp struct {
+	kind opKind
+	line string
+}
+
+type hunk struct {
+	aStart, aLen int
+	bStart, bLen int
+	ops          []diffOp
+}
+
+// splitLines splits s into lines, keeping the "\n" of each line so that a
+// missing newline at the end of the file shows up as a difference.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines computes a shortest edit script from a to b using the linear
+// space variant of Myers' O(ND) algorithm, so that large rewritten files
+// do not need memory quadratic in the number of changes.
+func diffLines(a, b []string) []diffOp {
+	var ops []diffOp
+	diffRange(a, b, &ops)
+	return ops
+}
+
+// diffRange appends the edit script from a to b to ops. It strips the
+// common prefix and suffix and splits what is left at a middle snake.
+func diffRange(a, b []string, ops *[]diffOp) {
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		*ops = append(*ops, diffOp{opEqual, a[0]})
+		a, b = a[1:], b[1:]
+	}
+	n := 0
+	for n < len(a) && n < len(b) && a[len(a)-1-n] == b[len(b)-1-n] {
+		n++
+	}
+	suffix := a[len(a)-n:]
+	a, b = a[:len(a)-n], b[:len(b)-n]
+
+	switch {
+	case len(a) == 0:
+		for _, line := range b {
+			*ops = append(*ops, diffOp{opInsert, line})
+		}
+	case len(b) == 0:
+		for _, line := range a {
+			*ops = append(*ops, diffOp{opDelete, line})
+		}
+	default:
+		// Both sides differ in their first and last line, so there are at
+		// least two edits and both halves around the snake need fewer.
+		x, y, u, v := middleSnake(a, b)
+		diffRange(a[:x], b[:y], ops)
+		for _, line := range a[x:u] {
+			*ops = append(*ops, diffOp{opEqual, line})
+		}
+		diffRange(a[u:], b[v:], ops)
+	}
+	for _, line := range suffix {
+		*ops = append(*ops, diffOp{opEqual, line})
+	}
+}
+
+// middleSnake finds the snake from (x, y) to (u, v) in the middle of a
+// shortest edit script from a to b by searching forwards from the start
+// and backwards from the end at the same time until the paths overlap.
+func middleSnake(a, b []string) (x, y, u, v int) {
+	n, m := len(a), len(b)
+	delta := n - m
+	odd := delta%2 != 0
+	max := (n + m + 1) / 2
+	offset := max + 1
+	// forward[offset+k] is the furthest x reached on diagonal k = x-y.
+	// backward[offset+k] is the same for the reversed sequences, where
+	// diagonal k corresponds to diagonal delta-k of the forward search.
+	forward := make([]int, 2*max+3)
+	backward := make([]int, 2*max+3)
+	for d := 0; d <= max; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y := x - k
+			x0, y0 := x, y
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+			if rk := delta - k; odd && rk >= -(d-1) && rk <= d-1 && x >= n-backward[offset+rk] {
+				return x0, y0, x, y
+			}
+		}
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && backward[offset+k-1] < backward[offset+k+1]) {
+				x = backward[offset+k+1]
+			} else {
+				x = backward[offset+k-1] + 1
+			}
+			y := x - k
+			x0, y0 := x, y
+			for x < n && y < m && a[n-1-x] == b[m-1-y] {
+				x++
+				y++
+			}
+			backward[offset+k] = x
+			if fk := delta - k; !odd && fk >= -d && fk <= d && forward[offset+fk] >= n-x {
+				return n - x, m - y, n - x0, m - y0
+			}
+		}
+	}
+	panic("diff: searches did not meet")
+}
+
+// hunks groups an edit script into unified diff hunks with up to context
+// unchanged lines around every change. Changes closer than 2*context
+// lines share a hunk.
+func hunks(ops []diffOp, context int) []hunk {
+	// aPos[i] and bPos[i] count the lines of a and b before ops[i].
+	aPos := make([]int, len(ops)+1)
+	bPos := make([]int, len(ops)+1)
+	for i, op := range ops {
+		aPos[i+1], bPos[i+1] = aPos[i], bPos[i]
+		if op.kind != opInsert {
+			aPos[i+1]++
+		}
+		if op.kind != opDelete {
+			bPos[i+1]++
+		}
+	}
+	var result []hunk
+	for i := 0; i < len(ops); {
+		if ops[i].kind == opEqual {
+			i++
+			continue
+		}
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		last := i
+		for j := i + 1; j < len(ops) && j-last <= 2*context+1; j++ {
+			if ops[j].kind != opEqual {
+				last = j
+			}
+		}
+		end := last + context + 1
+		if end > len(ops) {
+			end = len(ops)
+		}
+		result = append(result, hunk{
+			aStart: aPos[start], aLen: aPos[end] - aPos[start],
+			bStart: bPos[start], bLen: bPos[end] - bPos[start],
+			ops: ops[start:end],
+		})
+		i = end
+	}
+	return result
+}
+
+// printDiff prints a colourised unified diff between the files of two
+// versions of a gist, keyed by file name. Files present on one side only
+// are reported as added or removed, unless a file with identical content
+// exists on the other side under another name, which is reported as a
+// rename. It returns whether there were any differences.
+func printDiff(a, b map[string]string, aLabel, bLabel string) bool {
+	var removed, added, common []string
+	for name := range a {
+		if _, ok := b[name]; ok {
+			common = append(common, name)
+		} else {
+			removed = append(removed, name)
+		}
+	}
+	for name := range b {
+		if _, ok := a[name]; !ok {
+			added = append(added, name)
+		}
+	}
+	sort.Strings(removed)
+	sort.Strings(added)
+	sort.Strings(common)
+
+	bold := colour.New(colour.Bold)
+	changed := false
+	renamedTo := map[string]bool{}
+	for _, old := range removed {
+		renamed := ""
+		for _, name := range added {
+			if !renamedTo[name] && a[old] == b[name] {
+				renamed = name
+				break
+			}
+		}
+		changed = true
+		if renamed != "" {
+			renamedTo[renamed] = true
+			bold.Printf("renamed %s -> %s\n", old, renamed)
+			continue
+		}
+		bold.Printf("removed %s\n", old)
+		printFileDiff(old, "", a[old], "", aLabel, bLabel)
+	}
+	for _, name := range added {
+		if renamedTo[name] {
+			continue
+		}
+		changed = true
+		bold.Printf("added %s\n", name)
+		printFileDiff("", name, "", b[name], aLabel, bLabel)
+	}
+	for _, name := range common {
+		if a[name] == b[name] {
+			continue
+		}
+		changed = true
+		bold.Printf("modified %s\n", name)
+		printFileDiff(name, name, a[name], b[name], aLabel, bLabel)
+	}
+	return changed
+}
+
+// printFileDiff prints the unified diff of a single file. An empty name
+// stands for a file missing on that side.
+func printFileDiff(aName, bName, a, b, aLabel, bLabel string) {
+	header := colour.New(colour.Bold)
+	if aName == "" {
+		header.Println("--- /dev/null")
+	} else {
+		header.Printf("--- a/%s\t%s\n", aName, aLabel)
+	}
+	if bName == "" {
+		header.Println("+++ /dev/null")
+	} else {
+		header.Printf("+++ b/%s\t%s\n", bName, bLabel)
+	}
+	cyan := colour.New(colour.FgCyan)
+	red := colour.New(colour.FgRed)
+	green := colour.New(colour.FgGreen)
+	for _, h := range hunks(diffLines(splitLines(a), splitLines(b)), diffContext) {
+		cyan.Printf("@@ -%s +%s @@\n", hunkRange(h.aStart, h.aLen), hunkRange(h.bStart, h.bLen))
+		for _, op := range h.ops {
+			line := strings.TrimSuffix(op.line, "\n")
+			switch op.kind {
+			case opEqual:
+				fmt.Println(" " + line)
+			case opDelete:
+				red.Println("-" + line)
+			case opInsert:
+				green.Println("+" + line)
+			}
+			if !strings.HasSuffix(op.line, "\n") {
+				fmt.Println(`\ No newline at end of file`)
+			}
+		}
+	}
+}
+
+// hunkRange formats the start,length pair of a hunk header; start is 1-based
+// except for empty ranges, following diff -u.
+func hunkRange(start, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, length)
+}
+
+// gistContents returns the contents of the files of g by name.
+func gistContents(g *gist.Gist) map[string]string {
+	files := map[string]string{}
+	for name, f := range g.Files {
+		files[string(name)] = f.Content
+	}
+	return files
+}
+
+// shortRevision abbreviates a revision SHA the way git does.
+func shortRevision(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+func runDiff(ctx context.Context, o Options) int {
+	token, ok := authToken(o)
+	if !ok {
+		return 1
+	}
+	client := newClient(o, token)
+	id, revs := splitRevision(o.Diff)
+	from, to := revs, ""
+	if i := strings.Index(revs, ".."); i >= 0 {
+		from, to = revs[:i], revs[i+2:]
+	}
+	if len(o.Args) > 0 && to != "" {
+		fmt.Println("Compare local files against a single revision, ID or ID@SHA.")
+		return 1
+	}
+
+	show := func(rev string) (*gist.Gist, error) {
+		if rev == "" {
+			return client.ShowContext(ctx, id)
+		}
+		return client.ShowRevisionContext(ctx, id, rev)
+	}
+	label := func(g *gist.Gist, rev string) string {
+		if rev == "" && len(g.History) > 0 {
+			rev = g.History[0].Version
+		}
+		return shortRevision(rev)
+	}
+
+	// A revision against local files.
+	if len(o.Args) > 0 {
+		g, err := show(from)
+		if err != nil {
+			return printError(o, err, o.Diff)
+		}
+		remote := map[string]string{}
+		local := map[string]string{}
+		for _, path := range o.Args {
+			c, err := ioutil.ReadFile(path)
+			if err != nil {
+				fmt.Println(err)
+				return 1
+			}
+			name := filepath.Base(path)
+			local[name] = string(c)
+			if f, ok := g.Files[gist.GistFilename(name)]; ok {
+				remote[name] = f.Content
+			}
+		}
+		if !printDiff(remote, local, label(g, from), "local") {
+			fmt.Println("No differences.")
+		}
+		return 0
+	}
+
+	// Two revisions; the newer one defaults to the latest revision and
+	// the older one to the revision before it.
+	b, err := show(to)
+	if err != nil {
+		return printError(o, err, o.Diff)
+	}
+	if from == "" {
+		if len(b.History) < 2 {
+			fmt.Printf("Gist %s has no earlier revision.\n", id)
+			return 1
+		}
+		from = b.History[1].Version
+	}
+	a, err := show(from)
+	if err != nil {
+		return printError(o, err, o.Diff)
+	}
+	if !printDiff(gistContents(a), gistContents(b), label(a, from), label(b, to)) {
+		fmt.Println("No differences.")
+	}
+	return 0
+}
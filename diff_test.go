@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// script renders ops compactly, e.g. "=a -b +c".
+func script(ops []diffOp) string {
+	var s []string
+	for _, op := range ops {
+		s = append(s, string("=-+"[op.kind])+strings.TrimSuffix(op.line, "\n"))
+	}
+	return strings.Join(s, " ")
+}
+
+func lines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, " ")
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		edits int
+		want  string
+	}{
+		{a: "", b: "", edits: 0, want: ""},
+		{a: "a b c", b: "a b c", edits: 0, want: "=a =b =c"},
+		{a: "", b: "a b", edits: 2, want: "+a +b"},
+		{a: "a b", b: "", edits: 2, want: "-a -b"},
+		{a: "a b c", b: "a x c", edits: 2, want: "=a -b +x =c"},
+		{a: "a b c", b: "a c", edits: 1, want: "=a -b =c"},
+		{a: "a c", b: "a b c", edits: 1, want: "=a +b =c"},
+		{a: "a b c d e", b: "x y z", edits: 8},
+		{a: "a b c a b b a", b: "c b a b a c", edits: 5},
+		{a: "x a b c y", b: "a b c", edits: 2, want: "-x =a =b =c -y"},
+		{a: "a b a b a b", b: "b a b a b a", edits: 2},
+	}
+	for _, tt := range tests {
+		a, b := lines(tt.a), lines(tt.b)
+		ops := diffLines(a, b)
+		checkScript(t, a, b, ops, tt.edits)
+		if tt.want != "" && script(ops) != tt.want {
+			t.Errorf("diffLines(%q, %q) = %q, want %q", tt.a, tt.b, script(ops), tt.want)
+		}
+	}
+}
+
+// TestDiffLinesLarge diffs two entirely different files, the worst case
+// for the number of edits.
+func TestDiffLinesLarge(t *testing.T) {
+	var a, b []string
+	for i := 0; i < 5000; i++ {
+		a = append(a, fmt.Sprintf("a%d\n", i))
+		b = append(b, fmt.Sprintf("b%d\n", i))
+	}
+	b[2500] = a[2500]
+	checkScript(t, a, b, diffLines(a, b), 9998)
+}
+
+// checkScript checks that ops turns a into b with the given number of
+// inserted and deleted lines.
+func checkScript(t *testing.T, a, b []string, ops []diffOp, edits int) {
+	t.Helper()
+	var gotA, gotB []string
+	n := 0
+	for _, op := range ops {
+		if op.kind != opInsert {
+			gotA = append(gotA, op.line)
+		}
+		if op.kind != opDelete {
+			gotB = append(gotB, op.line)
+		}
+		if op.kind != opEqual {
+			n++
+		}
+	}
+	if !reflect.DeepEqual(gotA, a) || !reflect.DeepEqual(gotB, b) {
+		t.Errorf("script %q does not turn %q into %q", script(ops), a, b)
+	}
+	if n != edits {
+		t.Errorf("script %q for %q to %q has %d edits, want %d", script(ops), a, b, n, edits)
+	}
+}
+
+func TestHunks(t *testing.T) {
+	tests := []struct {
+		a, b    string
+		context int
+		want    []string
+	}{
+		{a: "a b c", b: "a b c", context: 3, want: nil},
+		{a: "1 2 3 4 5 6 7 8 9", b: "1 2 3 4 x 6 7 8 9", context: 1,
+			want: []string{"-3,3 +3,3 =4 -5 +x =6"}},
+		{a: "1 2 3 4 5 6 7 8 9", b: "x 2 3 4 5 6 7 8 y", context: 1,
+			want: []string{"-0,2 +0,2 -1 +x =2", "-7,2 +7,2 =8 -9 +y"}},
+		{a: "1 2 3 4 5 6 7 8 9", b: "x 2 3 4 5 6 7 8 y", context: 3,
+			want: []string{"-0,4 +0,4 -1 +x =2 =3 =4", "-5,4 +5,4 =6 =7 =8 -9 +y"}},
+		{a: "1 2 3 4 5 6 7 8", b: "x 2 3 4 5 6 7 y", context: 3,
+			want: []string{"-0,8 +0,8 -1 +x =2 =3 =4 =5 =6 =7 -8 +y"}},
+		{a: "1 2", b: "1 2 3", context: 3, want: []string{"-0,2 +0,3 =1 =2 +3"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, h := range hunks(diffLines(lines(tt.a), lines(tt.b)), tt.context) {
+			got = append(got, fmt.Sprintf("-%d,%d +%d,%d %s", h.aStart, h.aLen, h.bStart, h.bLen, script(h.ops)))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("hunks(%q, %q, %d) = %q, want %q", tt.a, tt.b, tt.context, got, tt.want)
+		}
+	}
+}
+
+func TestHunkRange(t *testing.T) {
+	tests := []struct {
+		start, length int
+		want          string
+	}{
+		{0, 0, "0,0"},
+		{3, 0, "3,0"},
+		{0, 1, "1,1"},
+		{4, 7, "5,7"},
+	}
+	for _, tt := range tests {
+		if got := hunkRange(tt.start, tt.length); got != tt.want {
+			t.Errorf("hunkRange(%d, %d) = %q, want %q", tt.start, tt.length, got, tt.want)
+		}
+	}
+}
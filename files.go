@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lilic/gisty/gist"
+)
+
+// localFiles collects gist files from paths. A directory contributes the
+// regular files directly inside it, skipping hidden ones unless it is a
+// cloned gist, whose hidden files such as .gitignore belong to the gist;
+// only its metadata file is skipped then. Gists are flat, so nested
+// directories are rejected, except hidden ones such as .git, and so are two
+// paths that would end up with the same file name in the gist. The returned
+// map records the local path of every file.
+func localFiles(paths []string, cloned bool) (map[gist.GistFilename]string, error) {
+	files := map[gist.GistFilename]string{}
+	add := func(path string) error {
+		name := gist.GistFilename(filepath.Base(path))
+		if other, ok := files[name]; ok {
+			return fmt.Errorf("%s and %s would both be named %s in the gist", other, path, name)
+		}
+		files[name] = path
+		return nil
+	}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			if err := add(path); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		entries, err := ioutil.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			hidden := strings.HasPrefix(e.Name(), ".")
+			if e.Name() == metadataFile || hidden && (!cloned || e.IsDir()) {
+				continue
+			}
+			p := filepath.Join(path, e.Name())
+			if e.IsDir() {
+				return nil, fmt.Errorf("%s is a directory, gists cannot contain nested directories", p)
+			}
+			if !e.Mode().IsRegular() {
+				continue
+			}
+			if err := add(p); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return files, nil
+}
+
+// readFiles reads the files returned by localFiles into gist files.
+func readFiles(paths map[gist.GistFilename]string) (map[gist.GistFilename]gist.GistFile, error) {
+	files := map[gist.GistFilename]gist.GistFile{}
+	for name, path := range paths {
+		c, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if len(c) == 0 {
+			return nil, fmt.Errorf("%s is empty, gists cannot contain empty files", path)
+		}
+		files[name] = gist.GistFile{Content: string(c)}
+	}
+	return files, nil
+}
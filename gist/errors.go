@@ -0,0 +1,75 @@
+package gist
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIError is returned when the GitHub API answers with a non-2xx status.
+type APIError struct {
+	StatusCode       int               `json:"-"`
+	Message          string            `json:"message"`
+	DocumentationURL string            `json:"documentation_url,omitempty"`
+	Errors           []ValidationError `json:"errors,omitempty"`
+}
+
+// ValidationError describes a single problem with a request, as reported in
+// the errors array of a 422 response.
+type ValidationError struct {
+	Resource string `json:"resource"`
+	Field    string `json:"field"`
+	Code     string `json:"code"`
+	Message  string `json:"message,omitempty"`
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	s := fmt.Sprintf("github: %d %s", e.StatusCode, msg)
+	if len(e.Errors) > 0 {
+		details := make([]string, 0, len(e.Errors))
+		for _, ve := range e.Errors {
+			details = append(details, ve.Error())
+		}
+		s += " (" + strings.Join(details, "; ") + ")"
+	}
+	return s
+}
+
+func (e ValidationError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s %s: %s", e.Resource, e.Field, e.Code)
+}
+
+func hasStatus(err error, code int) bool {
+	e, ok := err.(*APIError)
+	return ok && e.StatusCode == code
+}
+
+// IsNotFound reports whether err is a 404 from the API. GitHub also answers
+// 404 for secret gists the token is not allowed to see.
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsUnauthorized reports whether err is a 401 from the API, i.e. the token
+// is missing, invalid or expired.
+func IsUnauthorized(err error) bool {
+	return hasStatus(err, http.StatusUnauthorized)
+}
+
+// IsForbidden reports whether err is a 403 from the API.
+func IsForbidden(err error) bool {
+	return hasStatus(err, http.StatusForbidden)
+}
+
+// IsValidation reports whether err is a 422 from the API, i.e. the request
+// body was rejected.
+func IsValidation(err error) bool {
+	return hasStatus(err, http.StatusUnprocessableEntity)
+}
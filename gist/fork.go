@@ -0,0 +1,41 @@
+package gist
+
+import "context"
+
+// Fork forks the gist with the given id into the authenticated user's
+// account and returns the new gist.
+func (c *Client) Fork(id string) (*Gist, error) {
+	return c.ForkContext(context.Background(), id)
+}
+
+func (c *Client) ForkContext(ctx context.Context, id string) (*Gist, error) {
+	gist := &Gist{}
+	err := c.newRequest("POST", "/"+id+"/forks").Context(ctx).Do().Handle(gist)
+	if err != nil {
+		return nil, err
+	}
+	return gist, nil
+}
+
+// ListForks returns the forks of the gist with the given id. A nil opt
+// fetches every page.
+func (c *Client) ListForks(id string, opt *ListOptions) ([]*Gist, error) {
+	return c.ListForksContext(context.Background(), id, opt)
+}
+
+func (c *Client) ListForksContext(ctx context.Context, id string, opt *ListOptions) ([]*Gist, error) {
+	if opt == nil {
+		opt = &ListOptions{}
+	}
+	return c.ListForksPagesContext(ctx, id, opt.PageSize()).collect(opt.Limit)
+}
+
+// ListForksPages returns a Pager over the forks of the gist with the given
+// id.
+func (c *Client) ListForksPages(id string, perPage int) *Pager {
+	return c.ListForksPagesContext(context.Background(), id, perPage)
+}
+
+func (c *Client) ListForksPagesContext(ctx context.Context, id string, perPage int) *Pager {
+	return newPager(ctx, c, c.url("/"+id+"/forks"), perPage)
+}
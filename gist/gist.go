@@ -2,12 +2,18 @@ package gist
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"io/ioutil"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 )
 
-const base = "https://api.github.com/gists"
+// DefaultBaseURL is the root of the public GitHub API. GitHub Enterprise
+// Server installations use https://<host>/api/v3 instead.
+const DefaultBaseURL = "https://api.github.com"
 
 type Gist struct {
 	ID          string                    `json:"id,omitempty"`
@@ -16,18 +22,67 @@ type Gist struct {
 	Files       map[GistFilename]GistFile `json:"files,omitempty"`
 	HTMLURL     string                    `json:"html_url,omitempty"`
 	UpdatedAt   time.Time                 `json:"updated_at,omitempty"`
+	Owner       *User                     `json:"owner,omitempty"`
+	History     []GistCommit              `json:"history,omitempty"`
+}
+
+// User is the owner of a gist. Anonymous gists have none.
+type User struct {
+	Login   string `json:"login"`
+	HTMLURL string `json:"html_url,omitempty"`
 }
 
 type GistFilename string
 
 type GistFile struct {
 	Content string `json:"content,omitempty"`
+	// Filename renames the file when set to a new name in an Update.
+	Filename string `json:"filename,omitempty"`
+	Size     int    `json:"size,omitempty"`
+	RawURL   string `json:"raw_url,omitempty"`
+	Language string `json:"language,omitempty"`
+	// Truncated is set by the API when Content holds only the start of a
+	// large file. Show and ShowRevision load the full content from RawURL
+	// and clear it; Update refuses to send a file that still has it set.
+	Truncated bool `json:"truncated,omitempty"`
+	// Removed deletes the file in an Update; it is sent as null.
+	Removed bool `json:"-"`
+}
+
+func (f GistFile) MarshalJSON() ([]byte, error) {
+	if f.Removed {
+		return []byte("null"), nil
+	}
+	// file has GistFile's fields but not this method.
+	type file GistFile
+	return json.Marshal(file(f))
+}
+
+// Client talks to the gists endpoints of a single GitHub API installation.
+type Client struct {
+	BaseURL    string
+	Token      string
+	HTTPClient *http.Client
+	// MaxRateLimitWait is the longest the Client sleeps for a rate limit
+	// to reset. Longer waits fail with a RateLimitError instead.
+	MaxRateLimitWait time.Duration
+	// Retry decides which failed requests are sent again.
+	Retry RetryPolicy
+	// Logf, if set, is called for every response and every rate limit
+	// wait.
+	Logf func(format string, v ...interface{})
+
+	mu   sync.Mutex
+	rate Rate
 }
+
 type Request struct {
 	method string
 	url    string
 	token  string
 	body   *Gist
+	ctx    context.Context
+	c      *Client
 }
 
 type Response struct {
@@ -35,10 +90,63 @@ type Response struct {
 	err  error
 }
 
+// NewClient returns a Client for the API rooted at baseURL, e.g.
+// https://api.github.com or https://github.example.com/api/v3. An empty
+// baseURL falls back to DefaultBaseURL.
+func NewClient(baseURL string, token string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{
+		BaseURL:    baseURL,
+		Token:      token,
+		HTTPClient: &http.Client{Timeout: DefaultTimeout},
+
+		MaxRateLimitWait: DefaultMaxRateLimitWait,
+		Retry:            DefaultRetryPolicy,
+	}
+}
+
+// Rate returns the quota reported by the most recent response.
+func (c *Client) Rate() Rate {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rate
+}
+
+func (c *Client) setRate(r Rate) {
+	c.mu.Lock()
+	c.rate = r
+	c.mu.Unlock()
+}
+
+func (c *Client) logf(format string, v ...interface{}) {
+	if c.Logf != nil {
+		c.Logf(format, v...)
+	}
+}
+
+func (c *Client) url(path string) string {
+	return strings.TrimRight(c.BaseURL, "/") + "/gists" + path
+}
+
+func (c *Client) newRequest(method string, path string) *Request {
+	return c.request(method, c.url(path))
+}
+
+// request is like newRequest but takes an absolute URL, e.g. from a Link
+// header.
+func (c *Client) request(method string, url string) *Request {
+	r := newRequest(method, url).Token(c.Token)
+	r.c = c
+	return r
+}
+
 func newRequest(method string, url string) *Request {
 	return &Request{
 		method: method,
 		url:    url,
+		ctx:    context.Background(),
 	}
 }
 
@@ -52,71 +160,247 @@ func (r *Request) Body(g *Gist) *Request {
 	return r
 }
 
+// Context sets the context that cancels the request, including any
+// retries and rate limit waits.
+func (r *Request) Context(ctx context.Context) *Request {
+	r.ctx = ctx
+	return r
+}
+
 func (r *Request) Do() *Response {
-	body := bytes.NewBuffer(nil)
+	var payload []byte
 	if r.body != nil {
-		err := json.NewEncoder(body).Encode(r.body)
+		var err error
+		payload, err = json.Marshal(r.body)
 		if err != nil {
 			return &Response{resp: nil, err: err}
 		}
 	}
-	req, err := http.NewRequest(r.method, r.url, body)
+	for retries, waits := 0, 0; ; {
+		resp, err := r.send(payload)
+		if r.c == nil {
+			return &Response{resp: resp, err: err}
+		}
+		if r.ctx.Err() != nil {
+			discard(resp)
+			return &Response{resp: nil, err: r.ctx.Err()}
+		}
+		if retries < r.c.Retry.MaxRetries && shouldRetry(r.method, resp, err) {
+			d := r.c.Retry.backoff(retries)
+			retries++
+			if err != nil {
+				r.c.logf("%s %s: %v, retrying in %s (%d/%d)", r.method, r.url, err, d, retries, r.c.Retry.MaxRetries)
+			} else {
+				r.c.logf("%s %s: %s, retrying in %s (%d/%d)", r.method, r.url, resp.Status, d, retries, r.c.Retry.MaxRetries)
+			}
+			discard(resp)
+			if err := sleep(r.ctx, d); err != nil {
+				return &Response{resp: nil, err: err}
+			}
+			continue
+		}
+		if err != nil {
+			return &Response{resp: nil, err: err}
+		}
+		rate, ok := parseRate(resp.Header)
+		if ok {
+			r.c.setRate(rate)
+			r.c.logf("%s %s: %s, rate limit %s", r.method, r.url, resp.Status, rate)
+		} else {
+			r.c.logf("%s %s: %s", r.method, r.url, resp.Status)
+		}
+		rlErr := checkRateLimit(resp, time.Now())
+		if rlErr == nil {
+			return &Response{resp: resp, err: nil}
+		}
+		if waits == maxRateLimitWaits || rlErr.RetryAfter > r.c.MaxRateLimitWait {
+			return &Response{resp: nil, err: rlErr}
+		}
+		waits++
+		r.c.logf("rate limited, waiting %s before retrying", rlErr.RetryAfter)
+		if err := sleep(r.ctx, rlErr.RetryAfter); err != nil {
+			return &Response{resp: nil, err: err}
+		}
+	}
+}
+
+// sleep waits for d or until ctx is done, whichever comes first.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// send performs a single HTTP round trip.
+func (r *Request) send(payload []byte) (*http.Response, error) {
+	req, err := http.NewRequest(r.method, r.url, bytes.NewReader(payload))
 	if err != nil {
-		return &Response{resp: nil, err: err}
+		return nil, err
 	}
+	req = req.WithContext(r.ctx)
 	if r.token != "" {
 		req.Header.Add("Authorization", "Token "+r.token)
 	}
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return &Response{resp: nil, err: err}
+	client := http.DefaultClient
+	if r.c != nil && r.c.HTTPClient != nil {
+		client = r.c.HTTPClient
+	}
+	return client.Do(req)
+}
+
+// nextURL returns the rel="next" link of a paginated response.
+func (r *Response) nextURL() string {
+	if r.resp == nil {
+		return ""
 	}
-	return &Response{resp: resp, err: nil}
+	return parseNextLink(r.resp.Header.Get("Link"))
 }
 
 func (r *Response) Handle(input interface{}) error {
-	if r.err != nil {
-		return r.err
+	if err := r.check(); err != nil {
+		return err
 	}
 	defer r.resp.Body.Close()
+	if input == nil || r.resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
 	return json.NewDecoder(r.resp.Body).Decode(input)
 }
 
-func Create(token string, requestGist *Gist) (*Gist, error) {
+// Bytes returns the undecoded response body, e.g. the content of a raw
+// file.
+func (r *Response) Bytes() ([]byte, error) {
+	if err := r.check(); err != nil {
+		return nil, err
+	}
+	defer r.resp.Body.Close()
+	return ioutil.ReadAll(r.resp.Body)
+}
+
+// check returns the error of the request, or an APIError for a non-2xx
+// response, whose body it consumes.
+func (r *Response) check() error {
+	if r.err != nil {
+		return r.err
+	}
+	if r.resp.StatusCode < 200 || r.resp.StatusCode > 299 {
+		defer r.resp.Body.Close()
+		apiErr := &APIError{StatusCode: r.resp.StatusCode}
+		// The body is informational only; a non-JSON body still yields
+		// an error carrying the status code.
+		json.NewDecoder(r.resp.Body).Decode(apiErr)
+		return apiErr
+	}
+	return nil
+}
+
+func (c *Client) Create(requestGist *Gist) (*Gist, error) {
+	return c.CreateContext(context.Background(), requestGist)
+}
+
+func (c *Client) CreateContext(ctx context.Context, requestGist *Gist) (*Gist, error) {
 	gist := &Gist{}
-	err := newRequest("POST", base).Token(token).Body(requestGist).Do().Handle(gist)
+	err := c.newRequest("POST", "").Context(ctx).Body(requestGist).Do().Handle(gist)
 	if err != nil {
 		return nil, err
 	}
 	return gist, nil
 }
 
-func Show(token string, id string) (*Gist, error) {
+func (c *Client) Show(id string) (*Gist, error) {
+	return c.ShowContext(context.Background(), id)
+}
+
+func (c *Client) ShowContext(ctx context.Context, id string) (*Gist, error) {
 	gist := &Gist{}
-	url := base + "/" + id
-	err := newRequest("GET", url).Token(token).Do().Handle(gist)
+	err := c.newRequest("GET", "/"+id).Context(ctx).Do().Handle(gist)
 	if err != nil {
 		return nil, err
 	}
+	if err := c.loadTruncated(ctx, gist); err != nil {
+		return nil, err
+	}
 	return gist, nil
 }
 
-func Update(token string, id string, requestGist *Gist) (*Gist, error) {
-	url := base + "/" + id
+func (c *Client) Update(id string, requestGist *Gist) (*Gist, error) {
+	return c.UpdateContext(context.Background(), id, requestGist)
+}
+
+func (c *Client) UpdateContext(ctx context.Context, id string, requestGist *Gist) (*Gist, error) {
+	for name, f := range requestGist.Files {
+		if f.Truncated && !f.Removed {
+			return nil, &TruncatedError{Filename: name}
+		}
+	}
 	gist := &Gist{}
-	err := newRequest("PATCH", url).Token(token).Body(requestGist).Do().Handle(gist)
+	err := c.newRequest("PATCH", "/"+id).Context(ctx).Body(requestGist).Do().Handle(gist)
 	if err != nil {
 		return nil, err
 	}
 	return gist, nil
 }
 
-func List(token string) ([]*Gist, error) {
-	gists := []*Gist{}
-	err := newRequest("GET", base).Token(token).Do().Handle(&gists)
-	if err != nil {
-		return nil, err
+// Delete deletes the gist with the given id.
+func (c *Client) Delete(id string) error {
+	return c.DeleteContext(context.Background(), id)
+}
+
+func (c *Client) DeleteContext(ctx context.Context, id string) error {
+	return c.newRequest("DELETE", "/"+id).Context(ctx).Do().Handle(nil)
+}
+
+// List returns the authenticated user's gists, newest first. A nil opt
+// fetches every page.
+func (c *Client) List(opt *ListOptions) ([]*Gist, error) {
+	return c.ListContext(context.Background(), opt)
+}
+
+func (c *Client) ListContext(ctx context.Context, opt *ListOptions) ([]*Gist, error) {
+	if opt == nil {
+		opt = &ListOptions{}
 	}
-	return gists, nil
+	return c.ListPagesContext(ctx, opt.PageSize()).collect(opt.Limit)
+}
+
+// ListPages returns a Pager over the authenticated user's gists, fetching
+// perPage gists per request.
+func (c *Client) ListPages(perPage int) *Pager {
+	return c.ListPagesContext(context.Background(), perPage)
+}
+
+// ListPagesContext is like ListPages; ctx applies to every page request.
+func (c *Client) ListPagesContext(ctx context.Context, perPage int) *Pager {
+	return newPager(ctx, c, c.url(""), perPage)
+}
+
+// Create, Show, Update, Delete and List use a Client for DefaultBaseURL. Use
+// NewClient and its ...Context methods for cancellation and deadlines.
+
+func Create(token string, requestGist *Gist) (*Gist, error) {
+	return NewClient(DefaultBaseURL, token).Create(requestGist)
+}
+
+func Show(token string, id string) (*Gist, error) {
+	return NewClient(DefaultBaseURL, token).Show(id)
+}
+
+func Update(token string, id string, requestGist *Gist) (*Gist, error) {
+	return NewClient(DefaultBaseURL, token).Update(id, requestGist)
+}
+
+func Delete(token string, id string) error {
+	return NewClient(DefaultBaseURL, token).Delete(id)
+}
+
+// List returns the first page of the user's gists, at most DefaultPerPage
+// of them, in a single request. Use Client.List with ListOptions, or
+// Client.ListPages, to fetch more.
+func List(token string) ([]*Gist, error) {
+	return NewClient(DefaultBaseURL, token).List(&ListOptions{Limit: DefaultPerPage})
 }
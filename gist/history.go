@@ -0,0 +1,69 @@
+package gist
+
+import (
+	"context"
+	"time"
+)
+
+// GistCommit is one revision of a gist.
+type GistCommit struct {
+	Version      string       `json:"version"`
+	CommittedAt  time.Time    `json:"committed_at"`
+	ChangeStatus ChangeStatus `json:"change_status"`
+	URL          string       `json:"url,omitempty"`
+	User         *User        `json:"user,omitempty"`
+}
+
+// ChangeStatus counts the lines changed by a revision.
+type ChangeStatus struct {
+	Total     int `json:"total"`
+	Additions int `json:"additions"`
+	Deletions int `json:"deletions"`
+}
+
+// History returns the revisions of the gist with the given id, newest
+// first. A nil opt fetches every page.
+func (c *Client) History(id string, opt *ListOptions) ([]GistCommit, error) {
+	return c.HistoryContext(context.Background(), id, opt)
+}
+
+func (c *Client) HistoryContext(ctx context.Context, id string, opt *ListOptions) ([]GistCommit, error) {
+	if opt == nil {
+		opt = &ListOptions{}
+	}
+	commits := []GistCommit{}
+	next := pageURL(c.url("/"+id+"/commits"), opt.PageSize())
+	for next != "" {
+		page := []GistCommit{}
+		res := c.request("GET", next).Context(ctx).Do()
+		if err := res.Handle(&page); err != nil {
+			return nil, err
+		}
+		for _, commit := range page {
+			commits = append(commits, commit)
+			if opt.Limit > 0 && len(commits) == opt.Limit {
+				return commits, nil
+			}
+		}
+		next = res.nextURL()
+	}
+	return commits, nil
+}
+
+// ShowRevision returns the gist with the given id as it was at revision
+// sha, which may be any prefix GitHub accepts.
+func (c *Client) ShowRevision(id string, sha string) (*Gist, error) {
+	return c.ShowRevisionContext(context.Background(), id, sha)
+}
+
+func (c *Client) ShowRevisionContext(ctx context.Context, id string, sha string) (*Gist, error) {
+	gist := &Gist{}
+	err := c.newRequest("GET", "/"+id+"/"+sha).Context(ctx).Do().Handle(gist)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.loadTruncated(ctx, gist); err != nil {
+		return nil, err
+	}
+	return gist, nil
+}
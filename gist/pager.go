@@ -0,0 +1,137 @@
+package gist
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	// MaxPerPage is the largest page size the API accepts.
+	MaxPerPage = 100
+	// DefaultPerPage is the page size the API uses when none is given.
+	DefaultPerPage = 30
+)
+
+// ListOptions controls how many gists List fetches.
+type ListOptions struct {
+	// PerPage is the page size, at most MaxPerPage. Zero uses the API
+	// default of 30.
+	PerPage int
+	// Limit stops listing after this many gists. Zero fetches every page.
+	Limit int
+}
+
+// PageSize returns the page size to request: PerPage if set, otherwise
+// Limit when a single page can hold it, to avoid fetching gists that are
+// thrown away.
+func (opt *ListOptions) PageSize() int {
+	if opt.PerPage == 0 && opt.Limit > 0 && opt.Limit < MaxPerPage {
+		return opt.Limit
+	}
+	return opt.PerPage
+}
+
+// Pager iterates over a paginated gist listing by following the Link
+// rel="next" header, one request per page:
+//
+//	p := c.ListPages(100)
+//	for p.Next() {
+//		for _, g := range p.Page() {
+//			...
+//		}
+//	}
+//	if err := p.Err(); err != nil {
+//		...
+//	}
+type Pager struct {
+	ctx  context.Context
+	c    *Client
+	next string
+	page []*Gist
+	err  error
+}
+
+func newPager(ctx context.Context, c *Client, url string, perPage int) *Pager {
+	return &Pager{ctx: ctx, c: c, next: pageURL(url, perPage)}
+}
+
+// pageURL adds the per_page parameter to the URL of a listing.
+func pageURL(url string, perPage int) string {
+	if perPage > MaxPerPage {
+		perPage = MaxPerPage
+	}
+	if perPage <= 0 {
+		return url
+	}
+	sep := "?"
+	if strings.Contains(url, "?") {
+		sep = "&"
+	}
+	return url + fmt.Sprintf("%sper_page=%d", sep, perPage)
+}
+
+// Next fetches the next page and reports whether there was one.
+func (p *Pager) Next() bool {
+	if p.err != nil || p.next == "" {
+		return false
+	}
+	page := []*Gist{}
+	res := p.c.request("GET", p.next).Context(p.ctx).Do()
+	if err := res.Handle(&page); err != nil {
+		p.err = err
+		p.page = nil
+		return false
+	}
+	p.page = page
+	p.next = res.nextURL()
+	return true
+}
+
+// Page returns the gists of the page fetched by the last call to Next.
+func (p *Pager) Page() []*Gist {
+	return p.page
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (p *Pager) Err() error {
+	return p.err
+}
+
+// collect drains p into a slice, stopping after limit gists if limit > 0.
+func (p *Pager) collect(limit int) ([]*Gist, error) {
+	gists := []*Gist{}
+	for p.Next() {
+		for _, g := range p.Page() {
+			gists = append(gists, g)
+			if limit > 0 && len(gists) == limit {
+				return gists, nil
+			}
+		}
+	}
+	if err := p.Err(); err != nil {
+		return nil, err
+	}
+	return gists, nil
+}
+
+// parseNextLink extracts the rel="next" URL from a Link header such as
+// `<https://api.github.com/gists?page=2>; rel="next", <...>; rel="last"`.
+func parseNextLink(header string) string {
+	for _, link := range strings.Split(header, ",") {
+		parts := strings.Split(link, ";")
+		if len(parts) < 2 {
+			continue
+		}
+		url := strings.TrimSpace(parts[0])
+		if !strings.HasPrefix(url, "<") || !strings.HasSuffix(url, ">") {
+			continue
+		}
+		for _, param := range parts[1:] {
+			if strings.TrimSpace(param) == `rel="next"` {
+				return url[1 : len(url)-1]
+			}
+		}
+	}
+	return ""
+}
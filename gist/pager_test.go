@@ -0,0 +1,48 @@
+package gist
+
+import "testing"
+
+func TestParseNextLink(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{name: "empty"},
+		{
+			name:   "first",
+			header: `<https://api.github.com/gists?page=2>; rel="next", <https://api.github.com/gists?page=5>; rel="last"`,
+			want:   "https://api.github.com/gists?page=2",
+		},
+		{
+			name:   "middle",
+			header: `<https://api.github.com/gists?page=1>; rel="prev", <https://api.github.com/gists?page=3>; rel="next", <https://api.github.com/gists?page=5>; rel="last"`,
+			want:   "https://api.github.com/gists?page=3",
+		},
+		{
+			name:   "last",
+			header: `<https://api.github.com/gists?page=1>; rel="first",<https://api.github.com/gists?page=4>; rel="next"`,
+			want:   "https://api.github.com/gists?page=4",
+		},
+		{
+			name:   "extra params",
+			header: `<https://api.github.com/gists?page=1>; rel="prev", <https://api.github.com/gists?page=3>; type="json"; rel="next"`,
+			want:   "https://api.github.com/gists?page=3",
+		},
+		{
+			name:   "no next",
+			header: `<https://api.github.com/gists?page=1>; rel="first", <https://api.github.com/gists?page=4>; rel="prev"`,
+		},
+		{
+			name:   "malformed",
+			header: `https://api.github.com/gists?page=2; rel="next", <broken; rel="next"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseNextLink(tt.header); got != tt.want {
+				t.Errorf("parseNextLink() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
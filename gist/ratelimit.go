@@ -0,0 +1,132 @@
+package gist
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMaxRateLimitWait is how long a new Client is willing to sleep for
+// a rate limit to reset before giving up with a RateLimitError.
+const DefaultMaxRateLimitWait = time.Minute
+
+// secondaryRateLimitWait is GitHub's advice for secondary rate limits that
+// come without a Retry-After header.
+const secondaryRateLimitWait = time.Minute
+
+// maxRateLimitWaits bounds how often a single request waits for a rate
+// limit before it gives up.
+const maxRateLimitWaits = 3
+
+// Rate is the API quota as reported by the X-RateLimit-* headers.
+type Rate struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+func (r Rate) String() string {
+	return fmt.Sprintf("%d/%d remaining, resets at %s", r.Remaining, r.Limit, r.Reset.Format("15:04:05"))
+}
+
+// RateLimitError is returned when the API refuses a request because the
+// primary or secondary rate limit was hit and the wait for it to reset
+// would exceed the Client's MaxRateLimitWait.
+type RateLimitError struct {
+	StatusCode int
+	Message    string
+	Rate       Rate
+	// RetryAfter is how long the API asked to wait before retrying.
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("github: rate limit exceeded, retry in %s: %s", e.RetryAfter, e.Message)
+}
+
+// IsRateLimited reports whether err is a RateLimitError.
+func IsRateLimited(err error) bool {
+	_, ok := err.(*RateLimitError)
+	return ok
+}
+
+// parseRate reads the X-RateLimit-* headers. ok is false if the response
+// carried none, e.g. because it came from a proxy.
+func parseRate(h http.Header) (rate Rate, ok bool) {
+	remaining := h.Get("X-RateLimit-Remaining")
+	if remaining == "" {
+		return rate, false
+	}
+	rate.Remaining, _ = strconv.Atoi(remaining)
+	rate.Limit, _ = strconv.Atoi(h.Get("X-RateLimit-Limit"))
+	if reset, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		rate.Reset = time.Unix(reset, 0)
+	}
+	return rate, true
+}
+
+// checkRateLimit returns a RateLimitError if resp is a 403 or 429 caused by
+// a rate limit. The body of such a response is consumed and closed; any
+// other response is left untouched.
+func checkRateLimit(resp *http.Response, now time.Time) *RateLimitError {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return nil
+	}
+	rate, hasRate := parseRate(resp.Header)
+	retryAfter := resp.Header.Get("Retry-After")
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	// Put the body back in case this turns out to be a plain 403.
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	apiErr := &APIError{}
+	json.Unmarshal(body, apiErr)
+
+	e := &RateLimitError{
+		StatusCode: resp.StatusCode,
+		Message:    apiErr.Message,
+		Rate:       rate,
+	}
+	switch {
+	case retryAfter != "":
+		e.RetryAfter = parseRetryAfter(retryAfter, now)
+	case hasRate && rate.Remaining == 0:
+		e.RetryAfter = waitUntil(rate.Reset, now)
+	case strings.Contains(strings.ToLower(apiErr.Message), "rate limit"):
+		e.RetryAfter = secondaryRateLimitWait
+	case resp.StatusCode == http.StatusTooManyRequests:
+		e.RetryAfter = secondaryRateLimitWait
+	default:
+		return nil
+	}
+	resp.Body.Close()
+	return e
+}
+
+// parseRetryAfter reads a Retry-After header, which is either a number of
+// seconds or an HTTP-date. A value that is neither waits as long as a
+// secondary rate limit without the header.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return waitUntil(t, now)
+	}
+	return secondaryRateLimitWait
+}
+
+// waitUntil returns the wait from now until t, which has second precision,
+// so the wait is rounded up to match.
+func waitUntil(t, now time.Time) time.Duration {
+	d := (t.Sub(now) + time.Second - 1) / time.Second * time.Second
+	if d < 0 {
+		return 0
+	}
+	return d
+}
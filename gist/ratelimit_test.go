@@ -0,0 +1,133 @@
+package gist
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckRateLimit(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	reset := strconv.FormatInt(now.Add(30*time.Second).Unix(), 10)
+	past := strconv.FormatInt(now.Add(-time.Minute).Unix(), 10)
+
+	tests := []struct {
+		name    string
+		status  int
+		header  map[string]string
+		body    string
+		limited bool
+		want    time.Duration
+	}{
+		{
+			name:   "plain 403",
+			status: 403,
+			header: map[string]string{"X-RateLimit-Remaining": "4999", "X-RateLimit-Reset": reset},
+			body:   `{"message": "Must have admin rights to Repository."}`,
+		},
+		{
+			name:   "plain 403 without headers",
+			status: 403,
+			body:   `{"message": "Forbidden"}`,
+		},
+		{
+			name:   "not a 403",
+			status: 404,
+			header: map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": reset},
+		},
+		{
+			name:    "primary limit",
+			status:  403,
+			header:  map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": reset},
+			body:    `{"message": "API rate limit exceeded"}`,
+			limited: true,
+			want:    30 * time.Second,
+		},
+		{
+			name:    "primary limit already reset",
+			status:  403,
+			header:  map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": past},
+			limited: true,
+		},
+		{
+			name:    "Retry-After seconds",
+			status:  403,
+			header:  map[string]string{"Retry-After": "120"},
+			limited: true,
+			want:    2 * time.Minute,
+		},
+		{
+			name:    "Retry-After over X-RateLimit-Reset",
+			status:  403,
+			header:  map[string]string{"Retry-After": "90", "X-RateLimit-Remaining": "0", "X-RateLimit-Reset": reset},
+			limited: true,
+			want:    90 * time.Second,
+		},
+		{
+			name:    "Retry-After date",
+			status:  429,
+			header:  map[string]string{"Retry-After": now.Add(45 * time.Second).Format(http.TimeFormat)},
+			limited: true,
+			want:    45 * time.Second,
+		},
+		{
+			name:    "Retry-After date in the past",
+			status:  429,
+			header:  map[string]string{"Retry-After": now.Add(-time.Hour).Format(http.TimeFormat)},
+			limited: true,
+		},
+		{
+			name:    "Retry-After unparseable",
+			status:  403,
+			header:  map[string]string{"Retry-After": "soon"},
+			limited: true,
+			want:    secondaryRateLimitWait,
+		},
+		{
+			name:    "secondary limit",
+			status:  403,
+			header:  map[string]string{"X-RateLimit-Remaining": "4000"},
+			body:    `{"message": "You have exceeded a secondary rate limit."}`,
+			limited: true,
+			want:    secondaryRateLimitWait,
+		},
+		{
+			name:    "429 without headers",
+			status:  429,
+			limited: true,
+			want:    secondaryRateLimitWait,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: tt.status,
+				Header:     http.Header{},
+				Body:       ioutil.NopCloser(strings.NewReader(tt.body)),
+			}
+			for k, v := range tt.header {
+				resp.Header.Set(k, v)
+			}
+			e := checkRateLimit(resp, now)
+			if !tt.limited {
+				if e != nil {
+					t.Fatalf("checkRateLimit() = %v, want nil", e)
+				}
+				// The body must still be there for the APIError.
+				if body, _ := ioutil.ReadAll(resp.Body); string(body) != tt.body {
+					t.Errorf("body = %q, want %q", body, tt.body)
+				}
+				return
+			}
+			if e == nil {
+				t.Fatal("checkRateLimit() = nil, want a RateLimitError")
+			}
+			if e.RetryAfter != tt.want {
+				t.Errorf("RetryAfter = %s, want %s", e.RetryAfter, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package gist
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// DefaultTimeout is the per-attempt timeout of a new Client's HTTPClient.
+const DefaultTimeout = 30 * time.Second
+
+// RetryPolicy controls how a Client retries requests that failed for
+// transient reasons: timeouts, dropped connections and 5xx responses.
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt. Zero
+	// disables retrying.
+	MaxRetries int
+	// MinBackoff is the base delay before the first retry; it doubles
+	// with every further retry up to MaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy is the RetryPolicy of a new Client.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 3,
+	MinBackoff: 500 * time.Millisecond,
+	MaxBackoff: 10 * time.Second,
+}
+
+// backoff returns the delay before retry number attempt (counting from 0):
+// exponential growth capped at MaxBackoff, with "equal jitter" so that a
+// batch of clients failing together does not retry in lockstep.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.MinBackoff
+	for i := 0; i < attempt && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// shouldRetry reports whether a request that ended with resp or err may be
+// sent again. GET, PUT, DELETE and PATCH are retried on transient network
+// errors and on 5xx responses; PATCH is included because the gist API
+// applies it as "set these files to this content", so repeating it is
+// harmless. POST creates a new gist every time it reaches the server, so
+// it is only retried when the connection could not be established at all.
+func shouldRetry(method string, resp *http.Response, err error) bool {
+	if method == "POST" {
+		return err != nil && isDialError(err)
+	}
+	if err != nil {
+		return isTransient(err)
+	}
+	switch resp.StatusCode {
+	case http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+func isTransient(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return isDialError(err) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// discard drains and closes the body of a response that is about to be
+// retried so the connection can be reused.
+func discard(resp *http.Response) {
+	if resp == nil {
+		return
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+}
@@ -0,0 +1,136 @@
+package gist
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestShouldRetry(t *testing.T) {
+	dial := &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+	reset := &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}
+	timeout := &net.DNSError{Err: "timeout", IsTimeout: true}
+
+	tests := []struct {
+		name   string
+		method string
+		status int
+		err    error
+		want   bool
+	}{
+		{name: "GET 502", method: "GET", status: 502, want: true},
+		{name: "GET 503", method: "GET", status: 503, want: true},
+		{name: "GET 404", method: "GET", status: 404},
+		{name: "GET 200", method: "GET", status: 200},
+		{name: "GET reset", method: "GET", err: reset, want: true},
+		{name: "GET timeout", method: "GET", err: timeout, want: true},
+		{name: "GET EOF", method: "GET", err: io.ErrUnexpectedEOF, want: true},
+		{name: "GET other error", method: "GET", err: errors.New("bad")},
+		{name: "PATCH 502", method: "PATCH", status: 502, want: true},
+		{name: "PATCH reset", method: "PATCH", err: reset, want: true},
+		{name: "DELETE 504", method: "DELETE", status: 504, want: true},
+		{name: "POST 502", method: "POST", status: 502},
+		{name: "POST reset", method: "POST", err: reset},
+		{name: "POST timeout", method: "POST", err: timeout},
+		{name: "POST dial", method: "POST", err: dial, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp *http.Response
+			if tt.err == nil {
+				resp = &http.Response{StatusCode: tt.status}
+			}
+			if got := shouldRetry(tt.method, resp, tt.err); got != tt.want {
+				t.Errorf("shouldRetry() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsDialError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, want: true},
+		{err: &wrapped{&net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}}, want: true},
+		{err: &net.OpError{Op: "read", Err: syscall.ECONNRESET}},
+		{err: io.EOF},
+	}
+	for _, tt := range tests {
+		if got := isDialError(tt.err); got != tt.want {
+			t.Errorf("isDialError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+type wrapped struct{ err error }
+
+func (w *wrapped) Error() string { return "wrapped: " + w.err.Error() }
+func (w *wrapped) Unwrap() error { return w.err }
+
+func TestBackoff(t *testing.T) {
+	p := RetryPolicy{MinBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	// The delay before each retry lies between half and all of the
+	// doubled MinBackoff, capped at MaxBackoff.
+	ceilings := []time.Duration{1, 2, 4, 5, 5, 5}
+	for attempt, ceiling := range ceilings {
+		ceiling *= time.Second
+		for i := 0; i < 100; i++ {
+			d := p.backoff(attempt)
+			if d < ceiling/2 || d > ceiling {
+				t.Fatalf("backoff(%d) = %s, want between %s and %s", attempt, d, ceiling/2, ceiling)
+			}
+		}
+	}
+	if d := (RetryPolicy{}).backoff(3); d != 0 {
+		t.Errorf("zero policy backoff = %s, want 0", d)
+	}
+}
+
+func TestClientRetries(t *testing.T) {
+	tests := []struct {
+		method   string
+		wantHits int
+		wantErr  bool
+	}{
+		{method: "POST", wantHits: 1, wantErr: true},
+		{method: "PATCH", wantHits: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			hits := 0
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				hits++
+				if hits == 1 {
+					w.WriteHeader(http.StatusBadGateway)
+					return
+				}
+				io.WriteString(w, `{"id": "abc"}`)
+			}))
+			defer ts.Close()
+
+			c := NewClient(ts.URL, "token")
+			c.Retry = RetryPolicy{MaxRetries: 3, MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+			g := &Gist{Files: map[GistFilename]GistFile{"a.txt": {Content: "a"}}}
+			var err error
+			if tt.method == "POST" {
+				_, err = c.CreateContext(context.Background(), g)
+			} else {
+				_, err = c.UpdateContext(context.Background(), "abc", g)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if hits != tt.wantHits {
+				t.Errorf("server was hit %d times, want %d", hits, tt.wantHits)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package gist
+
+import "context"
+
+// Star stars the gist with the given id for the authenticated user.
+func (c *Client) Star(id string) error {
+	return c.StarContext(context.Background(), id)
+}
+
+func (c *Client) StarContext(ctx context.Context, id string) error {
+	return c.newRequest("PUT", "/"+id+"/star").Context(ctx).Do().Handle(nil)
+}
+
+// Unstar removes the authenticated user's star from the gist.
+func (c *Client) Unstar(id string) error {
+	return c.UnstarContext(context.Background(), id)
+}
+
+func (c *Client) UnstarContext(ctx context.Context, id string) error {
+	return c.newRequest("DELETE", "/"+id+"/star").Context(ctx).Do().Handle(nil)
+}
+
+// IsStarred reports whether the authenticated user starred the gist. The
+// API answers 404 both for unstarred and for missing gists, so a missing
+// gist is reported as not starred.
+func (c *Client) IsStarred(id string) (bool, error) {
+	return c.IsStarredContext(context.Background(), id)
+}
+
+func (c *Client) IsStarredContext(ctx context.Context, id string) (bool, error) {
+	err := c.newRequest("GET", "/"+id+"/star").Context(ctx).Do().Handle(nil)
+	if IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ListStarred returns the gists starred by the authenticated user. A nil
+// opt fetches every page.
+func (c *Client) ListStarred(opt *ListOptions) ([]*Gist, error) {
+	return c.ListStarredContext(context.Background(), opt)
+}
+
+func (c *Client) ListStarredContext(ctx context.Context, opt *ListOptions) ([]*Gist, error) {
+	if opt == nil {
+		opt = &ListOptions{}
+	}
+	return c.ListStarredPagesContext(ctx, opt.PageSize()).collect(opt.Limit)
+}
+
+// ListStarredPages returns a Pager over the gists starred by the
+// authenticated user.
+func (c *Client) ListStarredPages(perPage int) *Pager {
+	return c.ListStarredPagesContext(context.Background(), perPage)
+}
+
+func (c *Client) ListStarredPagesContext(ctx context.Context, perPage int) *Pager {
+	return newPager(ctx, c, c.url("/starred"), perPage)
+}
@@ -0,0 +1,56 @@
+package gist
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// TruncatedError is returned by Update for a file whose content is known
+// to be incomplete, which would otherwise overwrite the full file.
+type TruncatedError struct {
+	Filename GistFilename
+}
+
+func (e *TruncatedError) Error() string {
+	return fmt.Sprintf("gist: refusing to update %s, its content is truncated", e.Filename)
+}
+
+// loadTruncated replaces the content of every truncated file of g with the
+// full content from its raw URL.
+func (c *Client) loadTruncated(ctx context.Context, g *Gist) error {
+	for name, f := range g.Files {
+		if !f.Truncated {
+			continue
+		}
+		if f.RawURL == "" {
+			return fmt.Errorf("gist: %s is truncated and has no raw URL", name)
+		}
+		r := c.request("GET", f.RawURL).Context(ctx)
+		// Raw URLs usually live on another host; only send the token to
+		// the API host it was issued for.
+		if !sameHost(f.RawURL, c.BaseURL) {
+			r.Token("")
+		}
+		content, err := r.Do().Bytes()
+		if err != nil {
+			return err
+		}
+		f.Content = string(content)
+		f.Truncated = false
+		g.Files[name] = f
+	}
+	return nil
+}
+
+func sameHost(a, b string) bool {
+	ua, err := url.Parse(a)
+	if err != nil {
+		return false
+	}
+	ub, err := url.Parse(b)
+	if err != nil {
+		return false
+	}
+	return ua.Host == ub.Host
+}
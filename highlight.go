@@ -0,0 +1,293 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	colour "github.com/fatih/color"
+	"github.com/lilic/gisty/gist"
+	"github.com/mattn/go-isatty"
+)
+
+// syntax describes just enough of a language to colour keywords, strings,
+// comments and numbers.
+type syntax struct {
+	keywords     map[string]bool
+	lineComments []string
+	blockComment [2]string
+	// quotes are the string delimiters; multiline ones may span lines
+	// and have no escapes, like Go raw strings.
+	quotes    string
+	multiline string
+}
+
+func words(s string) map[string]bool {
+	m := map[string]bool{}
+	for _, w := range strings.Fields(s) {
+		m[w] = true
+	}
+	return m
+}
+
+var cKeywords = "auto break case char const continue default do double else enum extern float for goto if inline int long register return short signed sizeof static struct switch typedef union unsigned void volatile while NULL true false bool"
+
+var syntaxes = map[string]*syntax{
+	"go": {
+		keywords:     words("break case chan const continue default defer else fallthrough for func go goto if import interface map package range return select struct switch type var nil true false iota"),
+		lineComments: []string{"//"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "\"'`",
+		multiline:    "`",
+	},
+	"c": {
+		keywords:     words(cKeywords + " class namespace template typename public private protected virtual new delete this nullptr using try catch throw"),
+		lineComments: []string{"//", "#"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "\"'",
+	},
+	"java": {
+		keywords:     words("abstract boolean break byte case catch char class const continue default do double else enum extends final finally float for if implements import instanceof int interface long new null package private protected public return short static super switch this throw throws try void while true false var val fun object when"),
+		lineComments: []string{"//"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "\"'",
+	},
+	"javascript": {
+		keywords:     words("async await break case catch class const continue debugger default delete do else export extends finally for from function if import in instanceof let new null return super switch this throw try typeof undefined var void while yield true false interface type enum implements"),
+		lineComments: []string{"//"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "\"'`",
+		multiline:    "`",
+	},
+	"rust": {
+		keywords:     words("as break const continue crate else enum extern false fn for if impl in let loop match mod move mut pub ref return self Self static struct super trait true type unsafe use where while async await dyn"),
+		lineComments: []string{"//"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "\"",
+	},
+	"python": {
+		keywords:     words("and as assert async await break class continue def del elif else except finally for from global if import in is lambda nonlocal not or pass raise return try while with yield None True False self"),
+		lineComments: []string{"#"},
+		quotes:       "\"'",
+	},
+	"ruby": {
+		keywords:     words("alias and begin break case class def defined? do else elsif end ensure false for if in module next nil not or redo rescue retry return self super then true undef unless until when while yield require"),
+		lineComments: []string{"#"},
+		quotes:       "\"'",
+	},
+	"shell": {
+		keywords:     words("if then else elif fi case esac for select while until do done in function time return exit export local readonly set unset shift source echo"),
+		lineComments: []string{"#"},
+		quotes:       "\"'",
+	},
+	"sql": {
+		keywords:     words("select from where and or not insert into values update set delete create table drop alter index join left right inner outer on as group by order having limit null is in like distinct union SELECT FROM WHERE AND OR NOT INSERT INTO VALUES UPDATE SET DELETE CREATE TABLE DROP ALTER INDEX JOIN LEFT RIGHT INNER OUTER ON AS GROUP BY ORDER HAVING LIMIT NULL IS IN LIKE DISTINCT UNION"),
+		lineComments: []string{"--"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "'\"",
+	},
+	"yaml": {
+		keywords:     words("true false null yes no on off"),
+		lineComments: []string{"#"},
+		quotes:       "\"'",
+	},
+	"json": {
+		keywords: words("true false null"),
+		quotes:   "\"",
+	},
+}
+
+// extensions and languages map file extensions and the language names of
+// the API to the syntaxes above.
+var extensions = map[string]string{
+	".go": "go", ".c": "c", ".h": "c", ".cc": "c", ".cpp": "c", ".hpp": "c", ".cs": "java",
+	".java": "java", ".kt": "java", ".scala": "java", ".swift": "java",
+	".js": "javascript", ".jsx": "javascript", ".ts": "javascript", ".tsx": "javascript", ".mjs": "javascript",
+	".rs": "rust", ".py": "python", ".rb": "ruby", ".sh": "shell", ".bash": "shell", ".zsh": "shell",
+	".sql": "sql", ".yml": "yaml", ".yaml": "yaml", ".toml": "yaml", ".json": "json",
+	"Dockerfile": "shell", "Makefile": "shell",
+}
+
+var languages = map[string]string{
+	"Go": "go", "C": "c", "C++": "c", "C#": "java", "Objective-C": "c",
+	"Java": "java", "Kotlin": "java", "Scala": "java", "Swift": "java",
+	"JavaScript": "javascript", "TypeScript": "javascript", "Rust": "rust",
+	"Python": "python", "Ruby": "ruby", "Shell": "shell", "Dockerfile": "shell", "Makefile": "shell",
+	"SQL": "sql", "PLpgSQL": "sql", "YAML": "yaml", "TOML": "yaml", "JSON": "json",
+}
+
+// syntaxFor picks the syntax of a file by the language GitHub detected,
+// falling back to its extension.
+func syntaxFor(name string, f gist.GistFile) *syntax {
+	if s, ok := syntaxes[languages[f.Language]]; ok {
+		return s
+	}
+	if s, ok := syntaxes[extensions[filepath.Ext(name)]]; ok {
+		return s
+	}
+	return syntaxes[extensions[name]]
+}
+
+// theme is the colour of every kind of token.
+type theme struct {
+	keyword, str, comment, number, lineNumber *colour.Color
+}
+
+var themes = map[string]theme{
+	"default": {
+		keyword:    colour.New(colour.FgBlue, colour.Bold),
+		str:        colour.New(colour.FgGreen),
+		comment:    colour.New(colour.FgHiBlack),
+		number:     colour.New(colour.FgCyan),
+		lineNumber: colour.New(colour.FgHiBlack),
+	},
+	"bright": {
+		keyword:    colour.New(colour.FgHiMagenta, colour.Bold),
+		str:        colour.New(colour.FgHiYellow),
+		comment:    colour.New(colour.FgHiBlack, colour.Italic),
+		number:     colour.New(colour.FgHiCyan),
+		lineNumber: colour.New(colour.FgYellow),
+	},
+	"mono": {
+		keyword:    colour.New(colour.Bold),
+		str:        colour.New(colour.Underline),
+		comment:    colour.New(colour.Faint),
+		number:     colour.New(colour.Reset),
+		lineNumber: colour.New(colour.Faint),
+	},
+}
+
+// themeNames lists the accepted --theme values, including "none".
+func themeNames() []string {
+	names := []string{"none"}
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// highlighting reports whether shown content should be highlighted: only
+// on a terminal, and not with --theme=none.
+func highlighting(o Options) bool {
+	return o.Theme != "none" && isatty.IsTerminal(os.Stdout.Fd())
+}
+
+// printHighlighted writes content with ANSI colours for s, which may be nil
+// for plain text, optionally prefixing every line with its number.
+func printHighlighted(w io.Writer, content string, s *syntax, t theme, lineNumbers bool) {
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	width := len(fmt.Sprint(len(lines)))
+	var state string // the delimiter of an open block comment or string
+	for i, line := range lines {
+		if lineNumbers {
+			t.lineNumber.Fprintf(w, "%*d │ ", width, i+1)
+		}
+		if s == nil {
+			fmt.Fprintln(w, line)
+			continue
+		}
+		state = highlightLine(w, line, s, t, state)
+		fmt.Fprintln(w)
+	}
+}
+
+// highlightLine writes one line and returns the delimiter that closes a
+// block comment or multiline string still open at its end.
+func highlightLine(w io.Writer, line string, s *syntax, t theme, state string) string {
+	i := 0
+	if state != "" {
+		c := t.str
+		if state == s.blockComment[1] {
+			c = t.comment
+		}
+		end := strings.Index(line, state)
+		if end < 0 {
+			c.Fprint(w, line)
+			return state
+		}
+		i = end + len(state)
+		c.Fprint(w, line[:i])
+	}
+	for i < len(line) {
+		rest := line[i:]
+		if prefixAny(rest, s.lineComments) {
+			t.comment.Fprint(w, rest)
+			return ""
+		}
+		if s.blockComment[0] != "" && strings.HasPrefix(rest, s.blockComment[0]) {
+			end := strings.Index(rest[len(s.blockComment[0]):], s.blockComment[1])
+			if end < 0 {
+				t.comment.Fprint(w, rest)
+				return s.blockComment[1]
+			}
+			n := len(s.blockComment[0]) + end + len(s.blockComment[1])
+			t.comment.Fprint(w, rest[:n])
+			i += n
+			continue
+		}
+		r := rune(line[i])
+		switch {
+		case strings.ContainsRune(s.quotes, r):
+			n, open := scanString(rest, r, strings.ContainsRune(s.multiline, r))
+			t.str.Fprint(w, rest[:n])
+			if open {
+				return string(r)
+			}
+			i += n
+		case unicode.IsDigit(r) && (i == 0 || !isWord(rune(line[i-1]))):
+			n := 1
+			for n < len(rest) && (isWord(rune(rest[n])) || rest[n] == '.') {
+				n++
+			}
+			t.number.Fprint(w, rest[:n])
+			i += n
+		case isWord(r):
+			n := 1
+			for n < len(rest) && (isWord(rune(rest[n])) || rest[n] == '?') {
+				n++
+			}
+			if s.keywords[rest[:n]] {
+				t.keyword.Fprint(w, rest[:n])
+			} else {
+				fmt.Fprint(w, rest[:n])
+			}
+			i += n
+		default:
+			fmt.Fprint(w, string(line[i]))
+			i++
+		}
+	}
+	return ""
+}
+
+// scanString returns the length of the string literal at the start of s,
+// and whether it is a multiline literal left open at the end of the line.
+func scanString(s string, quote rune, multiline bool) (int, bool) {
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && !multiline:
+			i++
+		case rune(s[i]) == quote:
+			return i + 1, false
+		}
+	}
+	return len(s), multiline
+}
+
+func prefixAny(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func isWord(r rune) bool {
+	return r == '_' || r >= 0x80 || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	colour "github.com/fatih/color"
 	"github.com/lilic/gisty/gist"
@@ -11,45 +13,207 @@ import (
 	"log"
 	"os"
 	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sort"
 	"strings"
+	"syscall"
+	"time"
 )
 
 const (
-	githubToken = "GITHUB_TOKEN"
-	editor      = "EDITOR"
+	githubToken  = "GITHUB_TOKEN"
+	githubAPIURL = "GITHUB_API_URL"
+	editor       = "EDITOR"
 )
 
 type Options struct {
-	Create   bool
-	Public   bool
-	Anon     bool
-	Desc     string
-	Content  string
-	Filename string
-	Show     string
-	Edit     string
-	List     bool
-}
-
-func printGist(g *gist.Gist) {
-	colour.Set(colour.FgYellow)
-	fmt.Printf("ID:  %s\n", g.ID)
-	colour.Unset()
-	fmt.Print("URL: ")
-	colour.Set(colour.Underline)
-	fmt.Println(g.HTMLURL)
-	colour.Unset()
-	fmt.Printf("Date: %s\n\n", g.UpdatedAt)
+	Create       bool
+	Public       bool
+	Anon         bool
+	Desc         string
+	DescPrefix   string
+	Content      string
+	Filename     string
+	Files        []string
+	Show         string
+	Edit         string
+	List         bool
+	Delete       []string
+	Yes          bool
+	Star         string
+	Unstar       string
+	IsStarred    string
+	Starred      bool
+	History      string
+	Diff         string
+	Gist         string
+	RenameFile   []string
+	RemoveFile   []string
+	Clone        string
+	Push         bool
+	Force        bool
+	Watch        bool
+	Interval     time.Duration
+	Debounce     time.Duration
+	Fork         string
+	Forks        string
+	PerPage      int
+	Limit        int
+	All          bool
+	APIURL       string
+	MaxWait      time.Duration
+	Retries      int
+	Timeout      time.Duration
+	Verbose      bool
+	Raw          bool
+	Output       string
+	Format       string
+	NoPager      bool
+	Profile      string
+	TokenEnv     string
+	TokenCommand string
+	Editor       string
+	Theme        string
+	LineNumbers  bool
+	Args         []string
+}
+
+func newClient(o Options, token string) *gist.Client {
+	c := gist.NewClient(o.APIURL, token)
+	c.MaxRateLimitWait = o.MaxWait
+	c.Retry.MaxRetries = o.Retries
+	c.HTTPClient.Timeout = o.Timeout
+	if o.Verbose {
+		c.Logf = log.Printf
+	}
+	return c
+}
+
+// authToken returns the token of the selected profile: the output of its
+// token-command, or the ENV variable named by its token-env, which defaults
+// to $GITHUB_TOKEN. It tells the user when there is none.
+func authToken(o Options) (string, bool) {
+	if o.TokenCommand != "" {
+		cmd := exec.Command("sh", "-c", o.TokenCommand)
+		cmd.Stderr = os.Stderr
+		out, err := cmd.Output()
+		token := strings.TrimSpace(string(out))
+		if err != nil || token == "" {
+			fmt.Printf("Authentication not possible. The %s did not print a token.\n", tokenSource(o))
+			return "", false
+		}
+		return token, true
+	}
+	token := os.Getenv(tokenEnv(o))
+	if token == "" {
+		fmt.Printf("Authentication not possible. %s is not set.\n", tokenSource(o))
+		return "", false
+	}
+	return token, true
+}
+
+func tokenEnv(o Options) string {
+	if o.TokenEnv != "" {
+		return o.TokenEnv
+	}
+	return githubToken
+}
+
+// tokenSource describes where the token comes from for messages.
+func tokenSource(o Options) string {
+	s := fmt.Sprintf("ENV variable $%s", tokenEnv(o))
+	if o.TokenCommand != "" {
+		s = fmt.Sprintf("token-command %q", o.TokenCommand)
+	}
+	if o.Profile != "" {
+		s += " of profile " + o.Profile
+	}
+	return s
+}
+
+// printGist prints the details of g, and the profile used to fetch it if
+// one was selected.
+func printGist(w io.Writer, g *gist.Gist, profile string) {
+	colour.New(colour.FgYellow).Fprintf(w, "ID:  %s\n", g.ID)
+	fmt.Fprint(w, "URL: ")
+	colour.New(colour.Underline).Fprintln(w, g.HTMLURL)
+	fmt.Fprintf(w, "Date: %s\n", g.UpdatedAt)
+	if len(g.History) > 0 {
+		fmt.Fprintf(w, "Revision: %s\n", g.History[0].Version)
+	}
+	if profile != "" {
+		fmt.Fprintf(w, "Profile: %s\n", profile)
+	}
+	fmt.Fprintln(w)
 	if g.Description != "" {
-		fmt.Println(g.Description)
+		fmt.Fprintln(w, g.Description)
+	}
+	for _, filename := range fileNames(g) {
+		fmt.Fprintln(w, filename)
+	}
+	fmt.Fprintln(w)
+}
+
+// printContent prints the named files of g, each under a heading. On a
+// terminal the content is highlighted with the --theme.
+func printContent(w io.Writer, o Options, g *gist.Gist, names []string) {
+	heading := colour.New(colour.Bold)
+	highlight := highlighting(o)
+	for _, name := range names {
+		f := g.Files[gist.GistFilename(name)]
+		heading.Fprintf(w, "==> %s <==\n", name)
+		if highlight && f.Content != "" {
+			printHighlighted(w, f.Content, syntaxFor(name, f), themes[o.Theme], o.LineNumbers)
+		} else {
+			fmt.Fprint(w, f.Content)
+			if f.Content != "" && !strings.HasSuffix(f.Content, "\n") {
+				fmt.Fprintln(w)
+			}
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// printGists prints gists in the --output format and returns the exit
+// code.
+func printGists(o Options, gists ...*gist.Gist) int {
+	p := newPrinter(os.Stdout, o, false)
+	for _, g := range gists {
+		p.Print(g)
+	}
+	if err := p.Flush(); err != nil {
+		log.Fatal(err)
 	}
-	for filename, _ := range g.Files {
-		fmt.Println(filename)
+	return 0
+}
+
+// printError explains a failed API call to the user on stderr, to keep it
+// out of piped output, and returns the exit code. id is the gist the call
+// was about, if any.
+func printError(o Options, err error, id string) int {
+	switch {
+	case err == context.Canceled:
+		fmt.Fprintln(os.Stderr, "Interrupted.")
+	case gist.IsNotFound(err) && id != "":
+		fmt.Fprintf(os.Stderr, "Cannot find gist for ID: %s.\n", id)
+	case gist.IsNotFound(err):
+		fmt.Fprintln(os.Stderr, "Not found.")
+	case gist.IsUnauthorized(err):
+		fmt.Fprintf(os.Stderr, "Authentication failed. Check that %s gives a valid token.\n", tokenSource(o))
+	case gist.IsForbidden(err):
+		fmt.Fprintf(os.Stderr, "Access denied: %s\n", err)
+	case gist.IsRateLimited(err):
+		fmt.Fprintf(os.Stderr, "GitHub API rate limit exceeded, try again in %s.\n", err.(*gist.RateLimitError).RetryAfter)
+	case gist.IsValidation(err):
+		fmt.Fprintf(os.Stderr, "Gist rejected: %s\n", err)
+	default:
+		log.Print(err)
 	}
-	fmt.Println()
+	return 1
 }
 
-func runCreate(o Options) int {
+func runCreate(ctx context.Context, o Options) int {
 	var content io.Reader
 
 	// Content from STDIN.
@@ -65,145 +229,506 @@ func runCreate(o Options) int {
 	if len(o.Content) > 0 {
 		content = strings.NewReader(o.Content)
 	}
-	if content == nil {
+
+	// Content from files and directories.
+	paths, err := localFiles(append(o.Files, o.Args...), false)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	files, err := readFiles(paths)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	if content != nil {
+		// Scripts often run gisty with an empty pipe as STDIN; gists
+		// cannot hold empty files, so only the given files are used then.
+		c, _ := ioutil.ReadAll(content)
+		name := gist.GistFilename(o.Filename)
+		if path, ok := paths[name]; ok && len(c) > 0 {
+			fmt.Printf("%s and the content from --content or STDIN would both be named %s, pick another --filename.\n", path, name)
+			return 1
+		}
+		if len(c) > 0 {
+			files[name] = gist.GistFile{Content: string(c)}
+		}
+	}
+	if len(files) == 0 {
 		fmt.Println("Content missing.")
 		return 1
 	}
 	// Create a user gist.
 	token := ""
 	if !o.Anon {
-		token = os.Getenv(githubToken)
-
-		if token == "" {
-			fmt.Printf("Authentication not possible. ENV variable $%s is not set.\n", githubToken)
+		var ok bool
+		if token, ok = authToken(o); !ok {
 			return 1
 		}
 	}
 
-	c, _ := ioutil.ReadAll(content)
 	requestGist := &gist.Gist{
 		Public:      o.Public,
-		Description: o.Desc,
-		Files: map[gist.GistFilename]gist.GistFile{
-			gist.GistFilename(o.Filename): gist.GistFile{
-				Content: string(c),
-			},
-		},
-	}
-	g, err := gist.Create(token, requestGist)
+		Description: strings.TrimSpace(o.DescPrefix + o.Desc),
+		Files:       files,
+	}
+	g, err := newClient(o, token).CreateContext(ctx, requestGist)
 	if err != nil {
-		log.Fatal(err)
+		return printError(o, err, "")
 	}
-	printGist(g)
-	return 0
+	return printGists(o, g)
 }
 
-func runShow(o Options) int {
-	token := os.Getenv(githubToken)
-	if token == "" {
-		fmt.Printf("Authentication not possible. ENV variable $%s is not set.\n", githubToken)
+func runShow(ctx context.Context, o Options) int {
+	token, ok := authToken(o)
+	if !ok {
 		return 1
 	}
-	g, err := gist.Show(token, o.Show)
+	var g *gist.Gist
+	var err error
+	if id, rev := splitRevision(o.Show); rev != "" {
+		g, err = newClient(o, token).ShowRevisionContext(ctx, id, rev)
+	} else {
+		g, err = newClient(o, token).ShowContext(ctx, id)
+	}
 	if err != nil {
-		log.Fatal(err)
+		return printError(o, err, o.Show)
 	}
-	if g.ID == "" {
-		fmt.Printf("Cannot find gist for ID: %s.\n", g.ID)
-		return 1
+
+	names := fileNames(g)
+	if len(o.Files) > 0 {
+		for _, name := range o.Files {
+			if _, ok := g.Files[gist.GistFilename(name)]; !ok {
+				fmt.Fprintf(os.Stderr, "Gist %s has no file %s.\n", o.Show, name)
+				return 1
+			}
+		}
+		names = o.Files
+	}
+	if o.Raw {
+		if len(names) != 1 {
+			fmt.Fprintf(os.Stderr, "Gist %s has %d files, select one with --file.\n", o.Show, len(names))
+			return 1
+		}
+		fmt.Print(g.Files[gist.GistFilename(names[0])].Content)
+		return 0
+	}
+	if o.Output != "" || o.Format != "" {
+		return printGists(o, g)
 	}
-	printGist(g)
+	out := newPagedOutput(o)
+	printGist(out, g, o.Profile)
+	printContent(out, o, g, names)
+	out.Close()
 	return 0
 }
 
-func runEdit(o Options) int {
-	token := os.Getenv(githubToken)
-	if token == "" {
-		fmt.Printf("Authentication not possible. ENV variable $%s is not set.\n", githubToken)
-		return 1
+// splitRevision splits an ID@SHA argument into the gist ID and revision.
+func splitRevision(arg string) (id string, rev string) {
+	if i := strings.Index(arg, "@"); i >= 0 {
+		return arg[:i], arg[i+1:]
 	}
-	e := os.Getenv(editor)
-	if e == "" {
-		e = "vim"
+	return arg, ""
+}
+
+func runHistory(ctx context.Context, o Options) int {
+	token, ok := authToken(o)
+	if !ok {
+		return 1
 	}
-	var content []byte
-	var filename string
-	g, err := gist.Show(token, o.Edit)
+	history, err := newClient(o, token).HistoryContext(ctx, o.History, listOptions(o))
 	if err != nil {
-		log.Fatal(err)
+		return printError(o, err, o.History)
+	}
+	for _, c := range history {
+		colour.Set(colour.FgYellow)
+		fmt.Print(c.Version)
+		colour.Unset()
+		fmt.Printf("  %s  ", c.CommittedAt)
+		colour.Set(colour.FgGreen)
+		fmt.Printf("+%d", c.ChangeStatus.Additions)
+		colour.Unset()
+		fmt.Print(" ")
+		colour.Set(colour.FgRed)
+		fmt.Printf("-%d", c.ChangeStatus.Deletions)
+		colour.Unset()
+		if c.User != nil {
+			fmt.Printf("  %s", c.User.Login)
+		}
+		fmt.Println()
 	}
-	if g.ID == "" {
-		fmt.Printf("Cannot find gist for ID: %s.\n", o.Edit)
+	return 0
+}
+
+func runEdit(ctx context.Context, o Options) int {
+	token, ok := authToken(o)
+	if !ok {
 		return 1
 	}
-	for f, gf := range g.Files {
-		content = []byte(gf.Content)
-		filename = string(f)
+	e := o.Editor
+	if e == "" {
+		e = os.Getenv(editor)
+	}
+	if e == "" {
+		e = "vim"
 	}
-	tmpFile, err := ioutil.TempFile(os.TempDir(), "gisty")
+	client := newClient(o, token)
+	g, err := client.ShowContext(ctx, o.Edit)
 	if err != nil {
-		log.Fatal(err)
+		return printError(o, err, o.Edit)
 	}
-	defer os.Remove(tmpFile.Name())
-	if _, err := tmpFile.Write(content); err != nil {
+
+	// Materialise every file of the gist in a temporary directory and
+	// open them all in the editor.
+	dir, err := ioutil.TempDir(os.TempDir(), "gisty")
+	if err != nil {
 		log.Fatal(err)
 	}
-	if err := tmpFile.Close(); err != nil {
-		log.Fatal(err)
+	names := fileNames(g)
+	args := strings.Fields(e)
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(g.Files[gist.GistFilename(name)].Content), 0600); err != nil {
+			log.Fatal(err)
+		}
+		args = append(args, path)
 	}
 
-	cmd := exec.Command(e, tmpFile.Name())
+	cmd := exec.Command(args[0], args[1:]...)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
-	err = cmd.Start()
-	if err != nil {
-		log.Fatal(err)
-	}
-	err = cmd.Wait()
-	if err != nil {
-		log.Fatal(err)
+	if err := cmd.Run(); err != nil {
+		// The editor may have failed after the files were changed, so keep
+		// them around.
+		fmt.Printf("Editor %s failed: %v\n", args[0], err)
+		fmt.Printf("Your changes were saved in %s.\n", dir)
+		return 1
 	}
 
-	c, err := ioutil.ReadFile(tmpFile.Name())
-	if err != nil {
-		log.Fatal(err)
+	// Send only the files that changed. A file emptied or deleted in the
+	// editor is removed from the gist, since gists cannot hold empty
+	// files. Anything else in the directory, such as editor backups, is
+	// ignored.
+	files := map[gist.GistFilename]gist.GistFile{}
+	for _, name := range names {
+		c, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil && !os.IsNotExist(err) {
+			log.Fatal(err)
+		}
+		if len(c) == 0 {
+			files[gist.GistFilename(name)] = gist.GistFile{Removed: true}
+			continue
+		}
+		if g.Files[gist.GistFilename(name)].Content != string(c) {
+			files[gist.GistFilename(name)] = gist.GistFile{Content: string(c)}
+		}
+	}
+	if len(files) == 0 {
+		os.RemoveAll(dir)
+		fmt.Println("No changes.")
+		return 0
+	}
+	var removed []string
+	for _, name := range names {
+		if files[gist.GistFilename(name)].Removed {
+			removed = append(removed, name)
+		}
+	}
+	if len(removed) == len(names) {
+		fmt.Println("Cannot remove every file of a gist, use --delete instead.")
+		fmt.Printf("Your changes were saved in %s.\n", dir)
+		return 1
+	}
+	for _, name := range removed {
+		fmt.Printf("Removing %s, it was emptied.\n", name)
 	}
 	requestGist := &gist.Gist{
 		Public:      o.Public,
 		Description: "",
-		Files: map[gist.GistFilename]gist.GistFile{
-			gist.GistFilename(filename): gist.GistFile{
-				Content: string(c),
-			},
-		},
+		Files:       files,
+	}
+	g, err = client.UpdateContext(ctx, o.Edit, requestGist)
+	if err != nil {
+		// Keep the edited files around so the changes are not lost.
+		code := printError(o, err, o.Edit)
+		fmt.Printf("Your changes were saved in %s.\n", dir)
+		return code
+	}
+	os.RemoveAll(dir)
+	return printGists(o, g)
+}
+
+func runChangeFiles(ctx context.Context, o Options) int {
+	if o.Gist == "" {
+		fmt.Println("Pass the ID of the gist to change with --gist.")
+		return 1
+	}
+	token, ok := authToken(o)
+	if !ok {
+		return 1
+	}
+	client := newClient(o, token)
+	g, err := client.ShowContext(ctx, o.Gist)
+	if err != nil {
+		return printError(o, err, o.Gist)
+	}
+
+	// Check every change against the current files first so that nothing
+	// is applied unless everything can be.
+	files := map[gist.GistFilename]gist.GistFile{}
+	targets := map[gist.GistFilename]bool{}
+	for _, arg := range o.RenameFile {
+		i := strings.Index(arg, "=")
+		if i <= 0 || i == len(arg)-1 {
+			fmt.Printf("Invalid --rename-file %q, expected old=new.\n", arg)
+			return 1
+		}
+		old, renamed := gist.GistFilename(arg[:i]), gist.GistFilename(arg[i+1:])
+		if _, ok := g.Files[old]; !ok {
+			fmt.Printf("Gist %s has no file %s.\n", o.Gist, old)
+			return 1
+		}
+		if _, ok := g.Files[renamed]; ok {
+			fmt.Printf("Gist %s already has a file %s.\n", o.Gist, renamed)
+			return 1
+		}
+		if _, ok := files[old]; ok {
+			fmt.Printf("Cannot rename %s twice.\n", old)
+			return 1
+		}
+		if targets[renamed] {
+			fmt.Printf("Cannot rename two files to %s.\n", renamed)
+			return 1
+		}
+		targets[renamed] = true
+		files[old] = gist.GistFile{Filename: string(renamed)}
+	}
+	removed := 0
+	for _, arg := range o.RemoveFile {
+		name := gist.GistFilename(arg)
+		if _, ok := g.Files[name]; !ok {
+			fmt.Printf("Gist %s has no file %s.\n", o.Gist, name)
+			return 1
+		}
+		if f, ok := files[name]; ok {
+			if f.Removed {
+				continue
+			}
+			fmt.Printf("Cannot both rename and remove %s.\n", name)
+			return 1
+		}
+		files[name] = gist.GistFile{Removed: true}
+		removed++
+	}
+	if removed == len(g.Files) {
+		fmt.Println("Cannot remove every file of a gist, use --delete instead.")
+		return 1
 	}
-	g, err = gist.Update(token, o.Edit, requestGist)
+
+	g, err = client.UpdateContext(ctx, o.Gist, &gist.Gist{Files: files})
 	if err != nil {
+		return printError(o, err, o.Gist)
+	}
+	return printGists(o, g)
+}
+
+func runList(ctx context.Context, o Options) int {
+	token, ok := authToken(o)
+	if !ok {
+		return 1
+	}
+	opt := listOptions(o)
+	pages := newClient(o, token).ListPagesContext(ctx, opt.PageSize())
+	return listGists(o, pages, opt.Limit)
+}
+
+// listGists prints up to limit gists of pages in the --output format,
+// through the pager if they do not fit on the screen.
+func listGists(o Options, pages *gist.Pager, limit int) int {
+	out := newPagedOutput(o)
+	defer out.Close()
+	p := newPrinter(out, o, true)
+	// A failed write stops the listing too, without fetching more pages;
+	// Flush reports it.
+	if err := eachGist(pages, limit, p.Print); err != nil && p.err == nil {
+		return printError(o, err, "")
+	}
+	// The user may quit the pager before the end of the list.
+	if err := p.Flush(); err != nil && !errors.Is(err, syscall.EPIPE) {
 		log.Fatal(err)
 	}
-	printGist(g)
 	return 0
 }
 
-func runList(o Options) int {
-	token := os.Getenv(githubToken)
-	if token == "" {
-		fmt.Printf("Authentication not possible. ENV variable $%s is not set.\n", githubToken)
+// listOptions returns the page size and the maximum number of gists to
+// list according to --per-page, --limit and --all.
+func listOptions(o Options) *gist.ListOptions {
+	opt := &gist.ListOptions{PerPage: o.PerPage, Limit: o.Limit}
+	if o.All {
+		opt.Limit = 0
+	}
+	return opt
+}
+
+// eachGist calls fn for every gist of pages as the pages arrive rather than
+// waiting for the whole listing, stopping after limit gists if limit > 0 or
+// at the first error from fn, which it returns.
+func eachGist(pages *gist.Pager, limit int, fn func(g *gist.Gist) error) error {
+	n := 0
+	for pages.Next() {
+		for _, g := range pages.Page() {
+			if err := fn(g); err != nil {
+				return err
+			}
+			n++
+			if limit > 0 && n == limit {
+				return nil
+			}
+		}
+	}
+	return pages.Err()
+}
+
+func runFork(ctx context.Context, o Options) int {
+	token, ok := authToken(o)
+	if !ok {
 		return 1
 	}
-	gists, err := gist.List(token)
+	g, err := newClient(o, token).ForkContext(ctx, o.Fork)
 	if err != nil {
-		log.Fatal(err)
+		return printError(o, err, o.Fork)
 	}
-	for _, g := range gists {
-		printGist(g)
+	return printGists(o, g)
+}
+
+func runForks(ctx context.Context, o Options) int {
+	token, ok := authToken(o)
+	if !ok {
+		return 1
+	}
+	opt := listOptions(o)
+	pages := newClient(o, token).ListForksPagesContext(ctx, o.Forks, opt.PageSize())
+	n := 0
+	err := eachGist(pages, opt.Limit, func(g *gist.Gist) error {
+		owner := "anonymous"
+		if g.Owner != nil {
+			owner = g.Owner.Login
+		}
+		colour.Set(colour.FgYellow)
+		fmt.Print(g.ID)
+		colour.Unset()
+		fmt.Printf("  %s  %s\n", owner, g.UpdatedAt)
+		n++
+		return nil
+	})
+	if err != nil {
+		return printError(o, err, o.Forks)
+	}
+	if n == 0 {
+		fmt.Printf("Gist %s has no forks.\n", o.Forks)
+	}
+	return 0
+}
+
+func runStar(ctx context.Context, o Options) int {
+	token, ok := authToken(o)
+	if !ok {
+		return 1
+	}
+	client := newClient(o, token)
+	switch {
+	case o.Star != "":
+		if err := client.StarContext(ctx, o.Star); err != nil {
+			return printError(o, err, o.Star)
+		}
+		fmt.Printf("Starred gist %s.\n", o.Star)
+	case o.Unstar != "":
+		if err := client.UnstarContext(ctx, o.Unstar); err != nil {
+			return printError(o, err, o.Unstar)
+		}
+		fmt.Printf("Unstarred gist %s.\n", o.Unstar)
+	case o.IsStarred != "":
+		starred, err := client.IsStarredContext(ctx, o.IsStarred)
+		if err != nil {
+			return printError(o, err, o.IsStarred)
+		}
+		if !starred {
+			fmt.Printf("Gist %s is not starred.\n", o.IsStarred)
+			return 1
+		}
+		fmt.Printf("Gist %s is starred.\n", o.IsStarred)
+	default:
+		opt := listOptions(o)
+		return listGists(o, client.ListStarredPagesContext(ctx, opt.PageSize()), opt.Limit)
 	}
 	return 0
 }
 
+// apiURL returns the API URL from $GITHUB_API_URL, if set.
+func apiURL() string {
+	if u := os.Getenv(githubAPIURL); u != "" {
+		return u
+	}
+	return gist.DefaultBaseURL
+}
+
+func runDelete(ctx context.Context, o Options) int {
+	token, ok := authToken(o)
+	if !ok {
+		return 1
+	}
+	client := newClient(o, token)
+	stdin := bufio.NewReader(os.Stdin)
+	code := 0
+	for _, id := range o.Delete {
+		if !o.Yes {
+			g, err := client.ShowContext(ctx, id)
+			if err != nil {
+				code = printError(o, err, id)
+				continue
+			}
+			fmt.Printf("Delete gist %s", id)
+			if g.Description != "" {
+				fmt.Printf(" %q", g.Description)
+			}
+			fmt.Printf(" with files %s? [y/N] ", strings.Join(fileNames(g), ", "))
+			answer, _ := stdin.ReadString('\n')
+			answer = strings.ToLower(strings.TrimSpace(answer))
+			if answer != "y" && answer != "yes" {
+				fmt.Printf("Skipped gist %s.\n", id)
+				continue
+			}
+		}
+		if err := client.DeleteContext(ctx, id); err != nil {
+			code = printError(o, err, id)
+			if ctx.Err() != nil {
+				return code
+			}
+			continue
+		}
+		fmt.Printf("Deleted gist %s.\n", id)
+	}
+	return code
+}
+
+// fileNames returns the names of the files in g in alphabetical order.
+func fileNames(g *gist.Gist) []string {
+	names := make([]string, 0, len(g.Files))
+	for f := range g.Files {
+		names = append(names, string(f))
+	}
+	sort.Strings(names)
+	return names
+}
+
 func Main() int {
 	options := Options{}
+	config, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid config: %v\n", err)
+		return 1
+	}
 	flags := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
 	flags.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage of %s:\n", os.Args[0])
@@ -213,24 +738,119 @@ func Main() int {
 	flags.BoolVar(&options.Public, "public", false, "create a public gist.")
 	flags.BoolVar(&options.Anon, "anon", false, "create an anonymous private gist.")
 	flags.StringVar(&options.Desc, "description", "", "specify gist description, if not provided will be left blank.")
+	flags.StringVar(&options.DescPrefix, "description-prefix", "", "prepend this to the description of created gists, usually set in the config file.")
 	flags.StringVar(&options.Content, "content", "", "specify content of the gist")
-	flags.StringVar(&options.Filename, "filename", "file1.txt", "specify name of the file.")
-	flags.StringVar(&options.Show, "show", "", "pass a gist ID and it displays a gist.")
+	flags.StringVar(&options.Filename, "filename", "file1.txt", "specify name of the file created from --content or STDIN.")
+	flags.StringArrayVar(&options.Files, "file", nil, "with --create, add a file, or all files of a directory, to the gist; file paths can also be passed as arguments. With --show, only display this file. Can be repeated.")
+	flags.BoolVar(&options.Raw, "raw", false, "with --show, print only the exact content of the file, without headers or colour.")
+	flags.StringVar(&options.Theme, "theme", "default", "with --show, colours used to highlight the content on a terminal: "+strings.Join(themeNames(), ", ")+".")
+	flags.BoolVar(&options.LineNumbers, "line-numbers", true, "with --show, number the lines of highlighted content.")
+	flags.StringVar(&options.Show, "show", "", "pass a gist ID and it displays a gist, or ID@SHA to display it at an older revision.")
 	flags.StringVar(&options.Edit, "edit", "", "pass a gist ID to be able to edit your gist.")
-	flags.BoolVar(&options.List, "list", false, "lists first 30 of your gists.")
+	flags.BoolVar(&options.List, "list", false, "lists your most recent gists, see --limit and --all.")
+	flags.StringSliceVar(&options.Delete, "delete", nil, "pass one or more gist IDs, comma separated or repeated, to delete them.")
+	flags.BoolVarP(&options.Yes, "yes", "y", false, "do not ask for confirmation before deleting.")
+	flags.StringVar(&options.Star, "star", "", "pass a gist ID to star it.")
+	flags.StringVar(&options.Unstar, "unstar", "", "pass a gist ID to remove your star from it.")
+	flags.StringVar(&options.IsStarred, "is-starred", "", "pass a gist ID to check whether you starred it, exits with 1 if not.")
+	flags.BoolVar(&options.Starred, "starred", false, "lists the gists you starred, see --limit and --all.")
+	flags.StringVar(&options.History, "history", "", "pass a gist ID to list its revisions, see --limit and --all.")
+	flags.StringVar(&options.Diff, "diff", "", "pass ID, ID@SHA or ID@SHA..SHA to diff two revisions of a gist, or followed by local file paths to diff a revision against them.")
+	flags.StringVar(&options.Gist, "gist", "", "pass a gist ID to change with --rename-file, --remove-file or --watch.")
+	flags.StringArrayVar(&options.RenameFile, "rename-file", nil, "rename a file of the --gist, given as old=new. Can be repeated.")
+	flags.StringArrayVar(&options.RemoveFile, "remove-file", nil, "remove a file from the --gist. Can be repeated.")
+	flags.StringVar(&options.Clone, "clone", "", "pass a gist ID to write its files to a directory, given as argument or named after the ID.")
+	flags.BoolVar(&options.Push, "push", false, "push the changes of a cloned gist directory, given as argument or the current one, back to the gist.")
+	flags.BoolVar(&options.Force, "force", false, "overwrite existing files when cloning, or remote changes when pushing.")
+	flags.BoolVar(&options.Watch, "watch", false, "watch the files given as arguments and update the --gist whenever they are saved.")
+	flags.DurationVar(&options.Interval, "interval", time.Second, "how often --watch checks the files for changes.")
+	flags.DurationVar(&options.Debounce, "debounce", 500*time.Millisecond, "how long --watch waits for the files to stop changing before updating the gist.")
+	flags.StringVar(&options.Fork, "fork", "", "pass a gist ID to fork it into your account.")
+	flags.StringVar(&options.Forks, "forks", "", "pass a gist ID to list its forks, see --limit and --all.")
+	flags.IntVar(&options.PerPage, "per-page", 0, "number of gists fetched per request when listing, at most 100.")
+	flags.IntVar(&options.Limit, "limit", 30, "maximum number of gists to list.")
+	flags.BoolVar(&options.All, "all", false, "list all of your gists, ignoring --limit.")
+	flags.StringVar(&options.Profile, "profile", "", "use the API URL, token and defaults of this profile of the config file, instead of $GISTY_PROFILE.")
+	flags.StringVar(&options.APIURL, "api-url", apiURL(), "specify the GitHub API URL, e.g. https://github.example.com/api/v3 for GitHub Enterprise.")
+	flags.DurationVar(&options.MaxWait, "max-wait", gist.DefaultMaxRateLimitWait, "longest time to wait for the API rate limit to reset before giving up.")
+	flags.IntVar(&options.Retries, "retries", gist.DefaultRetryPolicy.MaxRetries, "number of times a request is retried after a network error or server error.")
+	flags.DurationVar(&options.Timeout, "timeout", gist.DefaultTimeout, "timeout of a single API request, 0 for none.")
+	flags.StringVarP(&options.Output, "output", "o", "", "print gists as "+strings.Join(outputFormats, ", ")+" instead of text.")
+	flags.StringVar(&options.Format, "format", "", "print every gist with a Go text/template instead, e.g. '{{.ID}} {{.Description}}'. Helpers: ago, date, files, join, truncate, json.")
+	flags.BoolVar(&options.NoPager, "no-pager", false, "never pipe long output through $GISTY_PAGER, $PAGER or less -R.")
+	flags.BoolVarP(&options.Verbose, "verbose", "v", false, "log API requests and the remaining rate limit quota.")
 	flags.Parse(os.Args[1:])
+	options.Args = flags.Args()
+	if err := config.apply(flags, &options); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid config: %v\n", err)
+		return 1
+	}
+	if !validOutput(options.Output) {
+		fmt.Fprintf(os.Stderr, "Unknown --output %q, use one of %s.\n", options.Output, strings.Join(outputFormats, ", "))
+		return 1
+	}
+	if _, err := parseFormat(options.Format); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --format: %v\n", err)
+		return 1
+	}
+	if _, ok := themes[options.Theme]; !ok && options.Theme != "none" {
+		fmt.Fprintf(os.Stderr, "Unknown --theme %q, use one of %s.\n", options.Theme, strings.Join(themeNames(), ", "))
+		return 1
+	}
+
+	// Cancel in-flight requests on the first Ctrl-C; a second one kills
+	// gisty right away.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		signal.Stop(interrupt)
+		cancel()
+	}()
 
 	if options.Create {
-		return runCreate(options)
+		return runCreate(ctx, options)
 	}
 	if options.Show != "" {
-		return runShow(options)
+		return runShow(ctx, options)
 	}
 	if options.Edit != "" {
-		return runEdit(options)
+		return runEdit(ctx, options)
 	}
 	if options.List {
-		return runList(options)
+		return runList(ctx, options)
+	}
+	if len(options.Delete) > 0 {
+		return runDelete(ctx, options)
+	}
+	if options.History != "" {
+		return runHistory(ctx, options)
+	}
+	if options.Diff != "" {
+		return runDiff(ctx, options)
+	}
+	if options.Watch {
+		return runWatch(ctx, options)
+	}
+	if len(options.RenameFile) > 0 || len(options.RemoveFile) > 0 {
+		return runChangeFiles(ctx, options)
+	}
+	if options.Clone != "" {
+		return runClone(ctx, options)
+	}
+	if options.Push {
+		return runPush(ctx, options)
+	}
+	if options.Fork != "" {
+		return runFork(ctx, options)
+	}
+	if options.Forks != "" {
+		return runForks(ctx, options)
+	}
+	if options.Star != "" || options.Unstar != "" || options.IsStarred != "" || options.Starred {
+		return runStar(ctx, options)
 	}
 
 	flags.Usage()
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	"github.com/lilic/gisty/gist"
+)
+
+// outputFormats are the values accepted by --output. The empty format is
+// the coloured text of printGist.
+var outputFormats = []string{"json", "yaml", "table", "id", "url"}
+
+func validOutput(format string) bool {
+	if format == "" {
+		return true
+	}
+	for _, f := range outputFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// printer writes gists in the format chosen with --output. Formats that
+// need every gist up front, such as a JSON array or an aligned table, are
+// collected and written by Flush; the others are written right away.
+type printer struct {
+	w      io.Writer
+	format string
+	tmpl   *template.Template
+	list   bool
+	// profile is shown by printGist.
+	profile string
+	gists   []*gist.Gist
+	err     error
+}
+
+// newPrinter returns a printer to w for o.Format, or o.Output if no template
+// is given. With list set, JSON and YAML are written as a sequence even when
+// there is only one gist.
+func newPrinter(w io.Writer, o Options, list bool) *printer {
+	p := &printer{w: w, format: o.Output, list: list, profile: o.Profile}
+	if o.Format != "" {
+		// Main checks that the template parses.
+		p.tmpl, _ = parseFormat(o.Format)
+	}
+	return p
+}
+
+// Print writes g, or collects it for Flush. It returns the first error
+// writing to the output, after which nothing more is written; a pager quit
+// by the user shows up as such an error.
+func (p *printer) Print(g *gist.Gist) error {
+	if p.err != nil {
+		return p.err
+	}
+	var buf bytes.Buffer
+	switch {
+	case p.tmpl != nil:
+		if p.err = p.tmpl.Execute(&buf, g); p.err != nil {
+			return p.err
+		}
+		if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+	case p.format == "id":
+		fmt.Fprintln(&buf, g.ID)
+	case p.format == "url":
+		fmt.Fprintln(&buf, g.HTMLURL)
+	case p.format == "json", p.format == "yaml", p.format == "table":
+		p.gists = append(p.gists, g)
+		return nil
+	default:
+		printGist(&buf, g, p.profile)
+	}
+	_, p.err = p.w.Write(buf.Bytes())
+	return p.err
+}
+
+func (p *printer) Flush() error {
+	if p.err != nil || p.tmpl != nil {
+		return p.err
+	}
+	gists := make([]outputGist, len(p.gists))
+	for i, g := range p.gists {
+		gists[i] = outputGist{g, g.Public}
+	}
+	var v interface{} = gists
+	if !p.list && len(gists) == 1 {
+		v = gists[0]
+	}
+	switch p.format {
+	case "json":
+		enc := json.NewEncoder(p.w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case "yaml":
+		return writeYAML(p.w, v)
+	case "table":
+		return p.writeTable()
+	}
+	return nil
+}
+
+// outputGist is a gist as written by JSON and YAML output. Unlike in
+// request bodies, public is always included so that secret gists can be
+// told apart.
+type outputGist struct {
+	*gist.Gist
+	Public bool `json:"public"`
+}
+
+func (p *printer) writeTable() error {
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tUPDATED\tVISIBILITY\tFILES\tDESCRIPTION")
+	for _, g := range p.gists {
+		visibility := "secret"
+		if g.Public {
+			visibility = "public"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+			g.ID,
+			g.UpdatedAt.Format("2006-01-02 15:04"),
+			visibility,
+			strings.Join(fileNames(g), ","),
+			truncate(strings.Replace(g.Description, "\n", " ", -1), 50))
+	}
+	return tw.Flush()
+}
+
+// truncate shortens s to at most n runes, marking the cut with "...".
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	if n <= 3 {
+		return string(r[:n])
+	}
+	return string(r[:n-3]) + "..."
+}
+
+// formatFuncs are the helpers available to --format templates.
+var formatFuncs = template.FuncMap{
+	"ago":      ago,
+	"date":     func(layout string, t time.Time) string { return t.Format(layout) },
+	"files":    fileNames,
+	"join":     func(sep string, s []string) string { return strings.Join(s, sep) },
+	"truncate": func(n int, s string) string { return truncate(s, n) },
+	"json": func(v interface{}) (string, error) {
+		c, err := json.Marshal(v)
+		return string(c), err
+	},
+}
+
+// parseFormat parses a --format template, which is executed once for
+// every gist, e.g. '{{.ID}} {{files . | join ","}} {{ago .UpdatedAt}}'.
+func parseFormat(format string) (*template.Template, error) {
+	return template.New("format").Funcs(formatFuncs).Parse(format)
+}
+
+// ago describes how long ago t was in the largest sensible unit.
+func ago(t time.Time) string {
+	d := time.Since(t)
+	unit := func(n int64, name string) string {
+		if n == 1 {
+			return fmt.Sprintf("1 %s ago", name)
+		}
+		return fmt.Sprintf("%d %ss ago", n, name)
+	}
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return unit(int64(d/time.Minute), "minute")
+	case d < 24*time.Hour:
+		return unit(int64(d/time.Hour), "hour")
+	case d < 30*24*time.Hour:
+		return unit(int64(d/(24*time.Hour)), "day")
+	case d < 365*24*time.Hour:
+		return unit(int64(d/(30*24*time.Hour)), "month")
+	}
+	return unit(int64(d/(365*24*time.Hour)), "year")
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+)
+
+const (
+	gistyPager   = "GISTY_PAGER"
+	defaultPager = "less -R"
+)
+
+// pagedOutput writes to stdout, or through a pager once the output no
+// longer fits on the terminal. Lines are held back until then, so short
+// output is written directly and never waits for a pager to be closed.
+type pagedOutput struct {
+	command string
+	height  int
+	buf     bytes.Buffer
+	lines   int
+	cmd     *exec.Cmd
+	pipe    io.WriteCloser
+}
+
+// newPagedOutput returns the output for a command that may print more than
+// a screen. It only pages when stdout is a terminal and --no-pager is not
+// set; the pager is $GISTY_PAGER, $PAGER or less -R, and an empty one or
+// "cat" turns paging off too.
+func newPagedOutput(o Options) io.WriteCloser {
+	command := defaultPager
+	if p, ok := os.LookupEnv(gistyPager); ok {
+		command = p
+	} else if p, ok := os.LookupEnv("PAGER"); ok {
+		command = p
+	}
+	command = strings.TrimSpace(command)
+	if o.NoPager || command == "" || command == "cat" || !isatty.IsTerminal(os.Stdout.Fd()) {
+		return nopCloser{os.Stdout}
+	}
+	height := terminalHeight(os.Stdout.Fd())
+	if height == 0 {
+		height, _ = strconv.Atoi(os.Getenv("LINES"))
+	}
+	if height <= 0 {
+		height = 24
+	}
+	return &pagedOutput{command: command, height: height}
+}
+
+func (p *pagedOutput) Write(b []byte) (int, error) {
+	if p.pipe != nil {
+		return p.pipe.Write(b)
+	}
+	p.buf.Write(b)
+	p.lines += bytes.Count(b, []byte("\n"))
+	// Keep the last row free for the shell prompt.
+	if p.lines >= p.height {
+		if err := p.start(); err != nil {
+			// Without a pager the output goes to the terminal as is.
+			p.pipe = nopCloser{os.Stdout}
+		}
+		if _, err := p.pipe.Write(p.buf.Bytes()); err != nil {
+			return 0, err
+		}
+		p.buf.Reset()
+	}
+	return len(b), nil
+}
+
+// start runs the pager through the shell, like git does, so that
+// $GISTY_PAGER can hold arguments. less is told to keep colours unless
+// $LESS says otherwise.
+func (p *pagedOutput) start() error {
+	cmd := exec.Command("sh", "-c", p.command)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if _, ok := os.LookupEnv("LESS"); !ok {
+		cmd.Env = append(os.Environ(), "LESS=R")
+	}
+	pipe, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	p.cmd, p.pipe = cmd, pipe
+	return nil
+}
+
+// Close writes output that fit on the screen, or waits for the user to
+// quit the pager.
+func (p *pagedOutput) Close() error {
+	if p.pipe == nil {
+		_, err := os.Stdout.Write(p.buf.Bytes())
+		return err
+	}
+	p.pipe.Close()
+	if p.cmd != nil {
+		return p.cmd.Wait()
+	}
+	return nil
+}
+
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
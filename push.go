@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/lilic/gisty/gist"
+)
+
+func runPush(ctx context.Context, o Options) int {
+	token, ok := authToken(o)
+	if !ok {
+		return 1
+	}
+	dir := "."
+	if len(o.Args) > 0 {
+		dir = o.Args[0]
+	}
+	m, err := readCloneMetadata(dir)
+	if os.IsNotExist(err) {
+		fmt.Printf("%s is not a cloned gist, %s is missing.\n", dir, metadataFile)
+		return 1
+	}
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	paths, err := localFiles([]string{dir}, true)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	local, err := readFiles(paths)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	// Compare the local files with their state at the cloned revision.
+	files := map[gist.GistFilename]gist.GistFile{}
+	for _, name := range sortedNames(local) {
+		f := local[name]
+		hash, ok := m.Files[string(name)]
+		switch {
+		case !ok:
+			fmt.Printf("added    %s\n", name)
+		case hash != contentHash(f.Content):
+			fmt.Printf("modified %s\n", name)
+		default:
+			continue
+		}
+		files[name] = f
+	}
+	cloned := make([]string, 0, len(m.Files))
+	for name := range m.Files {
+		cloned = append(cloned, name)
+	}
+	sort.Strings(cloned)
+	for _, name := range cloned {
+		if _, ok := local[gist.GistFilename(name)]; !ok {
+			fmt.Printf("deleted  %s\n", name)
+			files[gist.GistFilename(name)] = gist.GistFile{Removed: true}
+		}
+	}
+	if len(files) == 0 {
+		fmt.Println("Nothing to push.")
+		return 0
+	}
+	if len(local) == 0 {
+		fmt.Println("Cannot delete every file of a gist, use --delete instead.")
+		return 1
+	}
+
+	// Only push with the identity the gist was cloned with, so that a
+	// token is never sent to another installation.
+	if m.Profile != o.Profile {
+		if m.Profile == "" {
+			fmt.Printf("%s was cloned without a profile, push it without --profile or $%s.\n", dir, gistyProfile)
+		} else {
+			fmt.Printf("%s was cloned with profile %s, push it with --profile=%s.\n", dir, m.Profile, m.Profile)
+		}
+		return 1
+	}
+	if m.APIURL != "" && strings.TrimSuffix(m.APIURL, "/") != strings.TrimSuffix(o.APIURL, "/") {
+		fmt.Printf("%s was cloned from %s, push it with --api-url=%s.\n", dir, m.APIURL, m.APIURL)
+		return 1
+	}
+	client := newClient(o, token)
+	remote, err := client.ShowContext(ctx, m.ID)
+	if err != nil {
+		return printError(o, err, m.ID)
+	}
+	if len(remote.History) > 0 && remote.History[0].Version != m.Revision && !o.Force {
+		fmt.Printf("Gist %s changed since revision %s, it is now at %s.\n", m.ID, shortRevision(m.Revision), shortRevision(remote.History[0].Version))
+		fmt.Println("Use --force to overwrite the remote changes.")
+		return 1
+	}
+
+	g, err := client.UpdateContext(ctx, m.ID, &gist.Gist{Files: files})
+	if err != nil {
+		return printError(o, err, m.ID)
+	}
+
+	// Record the pushed state. Files only present remotely are left out so
+	// that a later push does not take them for local deletions.
+	m.URL = g.HTMLURL
+	if len(g.History) > 0 {
+		m.Revision = g.History[0].Version
+	}
+	m.Files = map[string]string{}
+	for name, f := range local {
+		m.Files[string(name)] = contentHash(f.Content)
+	}
+	if err := writeCloneMetadata(dir, m); err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	fmt.Printf("Pushed to gist %s, now at revision %s.\n", g.ID, shortRevision(m.Revision))
+	return 0
+}
+
+// sortedNames returns the names of files in alphabetical order.
+func sortedNames(files map[gist.GistFilename]gist.GistFile) []gist.GistFilename {
+	names := make([]gist.GistFilename, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
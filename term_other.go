@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly
+
+package main
+
+// terminalHeight is unknown on this platform; $LINES is used instead.
+func terminalHeight(fd uintptr) int {
+	return 0
+}
@@ -0,0 +1,20 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+package main
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// terminalHeight returns the number of rows of the terminal at fd, or 0 if
+// fd is not a terminal.
+func terminalHeight(fd uintptr) int {
+	var ws struct{ row, col, xpixel, ypixel uint16 }
+	_, _, err := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(&ws)))
+	if err != 0 {
+		return 0
+	}
+	return int(ws.row)
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lilic/gisty/gist"
+)
+
+// fileState is what polling compares to notice that a file was saved.
+type fileState struct {
+	modTime time.Time
+	size    int64
+	exists  bool
+}
+
+func statFiles(paths map[gist.GistFilename]string) map[gist.GistFilename]fileState {
+	states := map[gist.GistFilename]fileState{}
+	for name, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			states[name] = fileState{}
+			continue
+		}
+		states[name] = fileState{modTime: info.ModTime(), size: info.Size(), exists: true}
+	}
+	return states
+}
+
+func sameStates(a, b map[gist.GistFilename]fileState) bool {
+	for name, s := range a {
+		if b[name] != s {
+			return false
+		}
+	}
+	return true
+}
+
+func runWatch(ctx context.Context, o Options) int {
+	if o.Gist == "" {
+		fmt.Println("Pass the ID of the gist to sync to with --gist.")
+		return 1
+	}
+	if len(o.Args) == 0 {
+		fmt.Println("Pass the files to watch as arguments.")
+		return 1
+	}
+	if o.Interval <= 0 {
+		fmt.Println("The --interval must be positive.")
+		return 1
+	}
+	token, ok := authToken(o)
+	if !ok {
+		return 1
+	}
+	paths, err := localFiles(o.Args, false)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	// Changes are detected against the content at start, so only edits
+	// made while watching are sent.
+	synced := map[gist.GistFilename]string{}
+	for name, path := range paths {
+		c, err := ioutil.ReadFile(path)
+		if err != nil {
+			fmt.Println(err)
+			return 1
+		}
+		synced[name] = string(c)
+	}
+	client := newClient(o, token)
+	if _, err := client.ShowContext(ctx, o.Gist); err != nil {
+		return printError(o, err, o.Gist)
+	}
+	fmt.Printf("Watching %d files for gist %s, press Ctrl-C to stop.\n", len(paths), o.Gist)
+
+	ticker := time.NewTicker(o.Interval)
+	defer ticker.Stop()
+	last := statFiles(paths)
+	pending := false
+	var changedAt time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			fmt.Println("Stopped watching.")
+			return 0
+		case now := <-ticker.C:
+			// Wait for the files to settle for the debounce period so
+			// that a burst of saves becomes a single update.
+			current := statFiles(paths)
+			if !sameStates(current, last) {
+				last = current
+				pending = true
+				changedAt = now
+				continue
+			}
+			if !pending || now.Sub(changedAt) < o.Debounce {
+				continue
+			}
+			pending = false
+			syncFiles(ctx, o, client, paths, synced)
+		}
+	}
+}
+
+// syncFiles sends the files whose content differs from synced to the gist
+// and records what was sent. Failures are reported but do not stop the
+// watch; the files are sent again with the next change.
+func syncFiles(ctx context.Context, o Options, client *gist.Client, paths map[gist.GistFilename]string, synced map[gist.GistFilename]string) {
+	files := map[gist.GistFilename]gist.GistFile{}
+	var names []string
+	for name, path := range paths {
+		c, err := ioutil.ReadFile(path)
+		if err != nil {
+			// Editors often replace files on save; a missing file is
+			// picked up again once it is back.
+			continue
+		}
+		if string(c) == synced[name] || len(c) == 0 {
+			continue
+		}
+		files[name] = gist.GistFile{Content: string(c)}
+		names = append(names, string(name))
+	}
+	if len(files) == 0 {
+		return
+	}
+	g, err := client.UpdateContext(ctx, o.Gist, &gist.Gist{Files: files})
+	if err != nil {
+		if ctx.Err() == nil {
+			printError(o, err, o.Gist)
+		}
+		return
+	}
+	for name, f := range files {
+		synced[name] = f.Content
+	}
+	revision := ""
+	if len(g.History) > 0 {
+		revision = shortRevision(g.History[0].Version)
+	}
+	sort.Strings(names)
+	fmt.Printf("%s Updated %s, revision %s.\n", time.Now().Format("15:04:05"), strings.Join(names, ", "), revision)
+}
@@ -0,0 +1,420 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// writeYAML writes v as a YAML document. v goes through its JSON encoding
+// first, so field names and omitempty rules match the JSON output; keys are
+// sorted because JSON objects are unordered.
+func writeYAML(w io.Writer, v interface{}) error {
+	c, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var data interface{}
+	dec := json.NewDecoder(bytes.NewReader(c))
+	dec.UseNumber()
+	if err := dec.Decode(&data); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	yamlNode(&buf, data, 0)
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// yamlNode writes a block collection or a scalar followed by a newline,
+// indenting nested lines by indent spaces.
+func yamlNode(buf *bytes.Buffer, v interface{}, indent int) {
+	pad := strings.Repeat(" ", indent)
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			buf.WriteString("{}\n")
+			return
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteString(pad)
+			}
+			buf.WriteString(yamlScalar(k) + ":")
+			yamlValue(buf, v[k], indent)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			buf.WriteString("[]\n")
+			return
+		}
+		for i, item := range v {
+			if i > 0 {
+				buf.WriteString(pad)
+			}
+			if isMultiline(item) {
+				buf.WriteString("-")
+				yamlValue(buf, item, indent)
+				continue
+			}
+			buf.WriteString("- ")
+			yamlNode(buf, item, indent+2)
+		}
+	default:
+		buf.WriteString(yamlScalar(v) + "\n")
+	}
+}
+
+// yamlValue writes the value of a mapping key or sequence item that was
+// just written at indent.
+func yamlValue(buf *bytes.Buffer, v interface{}, indent int) {
+	pad := strings.Repeat(" ", indent+2)
+	switch {
+	case isCollection(v) && !isEmpty(v):
+		buf.WriteString("\n" + pad)
+		yamlNode(buf, v, indent+2)
+	case isMultiline(v):
+		s := v.(string)
+		// A literal block keeps the content readable; "|-" strips the
+		// final newline the block would otherwise add.
+		buf.WriteString(" |")
+		if !strings.HasSuffix(s, "\n") {
+			buf.WriteString("-")
+		}
+		buf.WriteString("\n")
+		for _, line := range strings.SplitAfter(strings.TrimSuffix(s, "\n"), "\n") {
+			// Lines of only spaces need the indent too, or their spaces
+			// would be read as indentation.
+			if line != "\n" {
+				buf.WriteString(pad)
+			}
+			buf.WriteString(strings.TrimSuffix(line, "\n") + "\n")
+		}
+	default:
+		buf.WriteString(" ")
+		yamlNode(buf, v, indent+2)
+	}
+}
+
+func isCollection(v interface{}) bool {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		return true
+	}
+	return false
+}
+
+func isEmpty(v interface{}) bool {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// isMultiline reports whether v is a string that is best written as a
+// literal block. Strings a block cannot represent exactly, such as ones
+// starting with a space, are quoted instead.
+func isMultiline(v interface{}) bool {
+	s, ok := v.(string)
+	if !ok || !strings.Contains(strings.TrimSuffix(s, "\n"), "\n") {
+		return false
+	}
+	if strings.HasPrefix(s, " ") || strings.HasPrefix(s, "\n") || strings.HasSuffix(s, "\n\n") {
+		return false
+	}
+	for _, r := range s {
+		if r == '\r' || r == '\t' || (r < 0x20 && r != '\n') {
+			return false
+		}
+	}
+	return true
+}
+
+var plainYAML = regexp.MustCompile(`^[A-Za-z0-9_./][A-Za-z0-9_./@+ -]*$`)
+
+// yamlScalar formats a JSON scalar, quoting strings that YAML would
+// otherwise read as another type or that contain special characters.
+func yamlScalar(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return v.String()
+	case string:
+		if !plainYAML.MatchString(v) || strings.HasSuffix(v, " ") {
+			return strconv.Quote(v)
+		}
+		switch strings.ToLower(v) {
+		case "true", "false", "yes", "no", "on", "off", "null", "y", "n":
+			return strconv.Quote(v)
+		}
+		if _, err := strconv.ParseFloat(v, 64); err == nil {
+			return strconv.Quote(v)
+		}
+		return v
+	}
+	return strconv.Quote("")
+}
+
+// yamlLine is a line of a YAML document. text is the line without its
+// indentation, and blank is set for lines without content, such as
+// comments, which only matter inside literal blocks.
+type yamlLine struct {
+	n      int
+	indent int
+	raw    string
+	text   string
+	blank  bool
+}
+
+// readYAML parses the subset of YAML used by the config file and written by
+// writeYAML: nested mappings, sequences of scalars in block or flow style,
+// plain or quoted scalars and literal blocks, which are all read as strings.
+func readYAML(data []byte) (map[string]interface{}, error) {
+	var lines []yamlLine
+	for i, raw := range strings.Split(string(data), "\n") {
+		raw = strings.TrimSuffix(raw, "\r")
+		line := strings.TrimRight(raw, " \t")
+		text := strings.TrimLeft(line, " ")
+		blank := text == "" || strings.HasPrefix(text, "#") || text == "---"
+		lines = append(lines, yamlLine{i + 1, len(line) - len(text), raw, text, blank})
+	}
+	lines = skipBlank(lines)
+	if len(lines) == 0 {
+		return map[string]interface{}{}, nil
+	}
+	m, rest, err := yamlMapping(lines, lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if rest = skipBlank(rest); len(rest) > 0 {
+		return nil, fmt.Errorf("line %d: unexpected indentation", rest[0].n)
+	}
+	return m, nil
+}
+
+func skipBlank(lines []yamlLine) []yamlLine {
+	for len(lines) > 0 && lines[0].blank {
+		lines = lines[1:]
+	}
+	return lines
+}
+
+// yamlMapping reads the keys at indent from the start of lines, and returns
+// the lines after them.
+func yamlMapping(lines []yamlLine, indent int) (map[string]interface{}, []yamlLine, error) {
+	m := map[string]interface{}{}
+	for lines = skipBlank(lines); len(lines) > 0 && lines[0].indent == indent; lines = skipBlank(lines) {
+		l := lines[0]
+		lines = lines[1:]
+		if strings.HasPrefix(l.text, "\t") {
+			return nil, nil, fmt.Errorf("line %d: indent with spaces, not tabs", l.n)
+		}
+		i := strings.Index(l.text, ": ")
+		if i < 0 && strings.HasSuffix(l.text, ":") {
+			i = len(l.text) - 1
+		}
+		if i <= 0 || isYAMLItem(l.text) {
+			return nil, nil, fmt.Errorf("line %d: expected key: value", l.n)
+		}
+		key, err := yamlKey(strings.TrimSpace(l.text[:i]))
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %d: %v", l.n, err)
+		}
+		if _, ok := m[key]; ok {
+			return nil, nil, fmt.Errorf("line %d: duplicate key %s", l.n, key)
+		}
+		value := strings.TrimSpace(l.text[i+1:])
+		if value == "|" || value == "|-" {
+			m[key], lines = yamlBlock(lines, indent, value == "|-")
+			continue
+		}
+		if value != "" && !strings.HasPrefix(value, "#") {
+			if m[key], err = yamlFlow(value); err != nil {
+				return nil, nil, fmt.Errorf("line %d: %v", l.n, err)
+			}
+			continue
+		}
+		next := skipBlank(lines)
+		switch {
+		// A block sequence may be indented as much as its key.
+		case len(next) > 0 && next[0].indent >= indent && isYAMLItem(next[0].text):
+			m[key], lines, err = yamlSequence(next, next[0].indent)
+		case len(next) > 0 && next[0].indent > indent:
+			m[key], lines, err = yamlMapping(next, next[0].indent)
+		default:
+			m[key] = ""
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return m, lines, nil
+}
+
+// yamlSequence reads the "- item" lines at indent from the start of lines,
+// and returns the lines after them.
+func yamlSequence(lines []yamlLine, indent int) ([]interface{}, []yamlLine, error) {
+	var seq []interface{}
+	for lines = skipBlank(lines); len(lines) > 0 && lines[0].indent == indent && isYAMLItem(lines[0].text); lines = skipBlank(lines) {
+		l := lines[0]
+		lines = lines[1:]
+		value := strings.TrimSpace(l.text[1:])
+		if value == "|" || value == "|-" {
+			var item string
+			item, lines = yamlBlock(lines, indent, value == "|-")
+			seq = append(seq, item)
+			continue
+		}
+		item, rest, err := yamlString(value, "")
+		if err == nil {
+			err = yamlComment(rest)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %d: %v", l.n, err)
+		}
+		seq = append(seq, item)
+	}
+	return seq, lines, nil
+}
+
+// yamlBlock reads the content of a literal block, the lines after "|" that
+// are indented more than parent. The block keeps its final newline unless
+// strip is set for "|-".
+func yamlBlock(lines []yamlLine, parent int, strip bool) (string, []yamlLine) {
+	indent := -1
+	var content []string
+	for len(lines) > 0 {
+		raw := lines[0].raw
+		if strings.TrimLeft(raw, " ") == "" {
+			if indent >= 0 && len(raw) > indent {
+				content = append(content, raw[indent:])
+			} else {
+				content = append(content, "")
+			}
+			lines = lines[1:]
+			continue
+		}
+		n := len(raw) - len(strings.TrimLeft(raw, " "))
+		if n <= parent || (indent >= 0 && n < indent) {
+			break
+		}
+		if indent < 0 {
+			indent = n
+		}
+		content = append(content, raw[indent:])
+		lines = lines[1:]
+	}
+	s := strings.TrimRight(strings.Join(content, "\n"), "\n")
+	if !strip && s != "" {
+		s += "\n"
+	}
+	return s, lines
+}
+
+func isYAMLItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+func yamlKey(s string) (string, error) {
+	key, rest, err := yamlString(s, "")
+	if err == nil && rest != "" {
+		err = fmt.Errorf("unexpected %q after key", rest)
+	}
+	return key, err
+}
+
+// yamlFlow reads a scalar or a flow sequence of scalars such as [a, "b"].
+func yamlFlow(s string) (interface{}, error) {
+	if !strings.HasPrefix(s, "[") {
+		v, rest, err := yamlString(s, "")
+		if err != nil {
+			return nil, err
+		}
+		return v, yamlComment(rest)
+	}
+	seq := []interface{}{}
+	rest := strings.TrimSpace(s[1:])
+	for !strings.HasPrefix(rest, "]") {
+		if rest == "" {
+			return nil, fmt.Errorf("missing ] after %s", s)
+		}
+		item, r, err := yamlString(rest, ",]")
+		if err != nil {
+			return nil, err
+		}
+		if item == "" && !strings.ContainsAny(rest[:1], `"'`) {
+			return nil, fmt.Errorf("missing item in %s", s)
+		}
+		seq = append(seq, item)
+		rest = strings.TrimSpace(r)
+		switch {
+		case strings.HasPrefix(rest, ","):
+			rest = strings.TrimSpace(rest[1:])
+		case !strings.HasPrefix(rest, "]"):
+			return nil, fmt.Errorf("expected , or ] after %s in %s", item, s)
+		}
+	}
+	return seq, yamlComment(rest[1:])
+}
+
+// yamlString reads a quoted scalar, or a plain one up to a comment or any
+// of stops, and returns it with the rest of s.
+func yamlString(s, stops string) (string, string, error) {
+	switch {
+	case strings.HasPrefix(s, `"`):
+		for i := 1; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				i++
+			case '"':
+				v, err := strconv.Unquote(s[:i+1])
+				return v, s[i+1:], err
+			}
+		}
+		return "", "", fmt.Errorf("missing closing quote in %s", s)
+	case strings.HasPrefix(s, "'"):
+		for i := 1; i < len(s); i++ {
+			if s[i] != '\'' {
+				continue
+			}
+			if i+1 < len(s) && s[i+1] == '\'' {
+				i++
+				continue
+			}
+			return strings.Replace(s[1:i], "''", "'", -1), s[i+1:], nil
+		}
+		return "", "", fmt.Errorf("missing closing quote in %s", s)
+	}
+	end := len(s)
+	if i := strings.Index(s, " #"); i >= 0 {
+		end = i
+	}
+	if i := strings.IndexAny(s[:end], stops); stops != "" && i >= 0 {
+		end = i
+	}
+	return strings.TrimSpace(s[:end]), s[end:], nil
+}
+
+// yamlComment checks that nothing but a comment follows a value.
+func yamlComment(rest string) error {
+	if rest = strings.TrimSpace(rest); rest != "" && !strings.HasPrefix(rest, "#") {
+		return fmt.Errorf("unexpected %q", rest)
+	}
+	return nil
+}
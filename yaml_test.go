@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadYAML(t *testing.T) {
+	tests := []struct {
+		in   string
+		want map[string]interface{}
+		err  string
+	}{
+		{in: "", want: map[string]interface{}{}},
+		{in: "# only a comment\n---\n", want: map[string]interface{}{}},
+		{
+			in: "public: false\nfilename: notes.md # a comment\nempty:\n",
+			want: map[string]interface{}{
+				"public": "false", "filename": "notes.md", "empty": "",
+			},
+		},
+		{
+			in: `a: "x # y"` + "\nb: 'it''s'\nc: \"tab\\t\"\nd: a#b\n",
+			want: map[string]interface{}{
+				"a": "x # y", "b": "it's", "c": "tab\t", "d": "a#b",
+			},
+		},
+		{
+			in: "a: [x, \"y, z\", 'w']\nb: []\nc: [x, ]\n",
+			want: map[string]interface{}{
+				"a": []interface{}{"x", "y, z", "w"},
+				"b": []interface{}{},
+				"c": []interface{}{"x"},
+			},
+		},
+		{
+			in: "a:\n  - x\n  - \"y\" # comment\nb:\n- z\n",
+			want: map[string]interface{}{
+				"a": []interface{}{"x", "y"},
+				"b": []interface{}{"z"},
+			},
+		},
+		{
+			in: "profile: work\nprofiles:\n  work:\n    api-url: https://example.com/api/v3\n    file: [a]\n  home:\n    public: true\n",
+			want: map[string]interface{}{
+				"profile": "work",
+				"profiles": map[string]interface{}{
+					"work": map[string]interface{}{
+						"api-url": "https://example.com/api/v3",
+						"file":    []interface{}{"a"},
+					},
+					"home": map[string]interface{}{"public": "true"},
+				},
+			},
+		},
+		{in: "a: [\"x\" y]\n", err: "expected , or ]"},
+		{in: "a: [,]\n", err: "missing item"},
+		{in: "a: [x,,y]\n", err: "missing item"},
+		{in: "a: [x, y\n", err: "expected , or ]"},
+		{in: "a: [x,\n", err: "missing ]"},
+		{in: "a: \"x\" y\n", err: "unexpected"},
+		{in: "a: 'x\n", err: "missing closing quote"},
+		{in: "a: x\na: y\n", err: "line 2: duplicate key a"},
+		{in: "a:\n  b: x\n c: y\n", err: "line 3: unexpected indentation"},
+		{in: "just text\n", err: "line 1: expected key: value"},
+		{in: "- x\n", err: "line 1: expected key: value"},
+		{in: "a:\n\t- x\n", err: "line 2: indent with spaces"},
+	}
+	for _, tt := range tests {
+		got, err := readYAML([]byte(tt.in))
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("readYAML(%q) = %v, %v, want error containing %q", tt.in, got, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("readYAML(%q) failed: %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("readYAML(%q) = %#v, want %#v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWriteYAML(t *testing.T) {
+	tests := []map[string]interface{}{
+		{"content": "a\n      \nb\n"},
+		{"content": "a\n\n  b\nc"},
+		{"content": "a\n#not a comment\n   \n"},
+		{
+			"plain":  "hello world",
+			"quoted": "true",
+			"number": "1.5",
+			"colon":  "a: b # c",
+			"space":  "trailing ",
+			"empty":  "",
+			"line":   "one\n",
+		},
+		{
+			"files": map[string]interface{}{
+				"main.go":   map[string]interface{}{"content": "package main\n\nfunc main() {\n\t}\n"},
+				"notes.txt": map[string]interface{}{"content": "x\n  y\n"},
+			},
+		},
+		{
+			"list":  []interface{}{"a", "b c", "multi\n  line\n", "no newline\nat end"},
+			"empty": []interface{}{},
+		},
+	}
+	for _, v := range tests {
+		var buf bytes.Buffer
+		if err := writeYAML(&buf, v); err != nil {
+			t.Fatalf("writeYAML(%q) failed: %v", v, err)
+		}
+		got, err := readYAML(buf.Bytes())
+		if err != nil {
+			t.Errorf("readYAML of writeYAML(%q) failed: %v\n%s", v, err, buf.String())
+			continue
+		}
+		if !reflect.DeepEqual(got, v) {
+			t.Errorf("writeYAML(%q) read back as %q:\n%s", v, got, buf.String())
+		}
+	}
+}
+
+func TestWriteYAMLBlankLines(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeYAML(&buf, map[string]string{"content": "a\n      \n\nb\n"}); err != nil {
+		t.Fatal(err)
+	}
+	want := "content: |\n  a\n        \n\n  b\n"
+	if buf.String() != want {
+		t.Errorf("writeYAML() = %q, want %q", buf.String(), want)
+	}
+}